@@ -12,9 +12,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/yourusername/reco-engine/internal/api"
+	"github.com/yourusername/reco-engine/internal/health"
 	"github.com/yourusername/reco-engine/internal/store"
 	"github.com/yourusername/reco-engine/internal/util/config"
 	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 )
 
@@ -33,6 +36,19 @@ func main() {
 
 	logger.Info("Starting Recommendation API Service")
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Observability.Tracing, "reco-api")
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", zap.Error(err))
+		}
+	}()
+
 	// Initialize Redis
 	redisStore, err := store.NewRedisStore(cfg.Redis)
 	if err != nil {
@@ -48,16 +64,35 @@ func main() {
 	defer pgStore.Close()
 
 	// Initialize service
+	if err := api.ValidateExperiments(cfg.Experiments); err != nil {
+		logger.Fatal("Invalid experiment config", zap.Error(err))
+	}
 	svc := api.NewService(cfg, redisStore, pgStore)
 
+	// Load the served embedding and collaborative filtering models in the
+	// background and follow promotions and new versions
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.RunEmbeddingModel(ctx)
+	go svc.RunCFModel(ctx)
+
 	// Initialize handler
 	handler := api.NewHandler(svc)
 
+	// Readiness checks. Recommendations are served from Redis and filtered
+	// and hydrated with catalog data from PostgreSQL.
+	checker := health.NewChecker(cfg.Observability.Health.CheckTimeout)
+	checker.Register("redis", redisStore.Ping, true)
+	checker.Register("postgres", pgStore.Ping, true)
+
 	// Setup Gin router
 	if cfg.Observability.Logging.Format == "json" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	router := gin.Default()
+	router.Use(otelgin.Middleware("reco-api", otelgin.WithFilter(func(r *http.Request) bool {
+		return !health.IsProbe(r)
+	})))
 
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
@@ -73,8 +108,11 @@ func main() {
 
 	// Routes
 	router.GET("/health", handler.HandleHealth)
+	checker.RegisterRoutes(router)
 	router.GET("/recommendations", handler.HandleGetRecommendations)
 	router.GET("/popular", handler.HandleGetPopular)
+	router.GET("/items/:id/similar", handler.HandleGetSimilarItems)
+	router.GET("/items/:id/bought-together", handler.HandleGetBoughtTogether)
 
 	// Metrics endpoint
 	if cfg.Observability.Metrics.Enabled {
@@ -98,20 +136,55 @@ func main() {
 		}
 	}()
 
+	// Model registry server. It listens on its own port, outside the CORS
+	// policy above, and only when an admin token is set.
+	var adminSrv *http.Server
+	if cfg.Server.API.AdminToken == "" {
+		logger.Warn("Admin endpoints are disabled, set server.api.admin_token to enable them")
+	} else {
+		adminRouter := gin.Default()
+		admin := adminRouter.Group("/admin", api.RequireAdminToken(cfg.Server.API.AdminToken))
+		admin.GET("/models", handler.HandleListModels)
+		admin.GET("/models/:name/versions/:version", handler.HandleGetModel)
+		admin.POST("/models/:name/versions/:version/promote", handler.HandlePromoteModel)
+		admin.POST("/models/:name/rollback", handler.HandleRollbackModel)
+
+		adminAddr := fmt.Sprintf("%s:%d", cfg.Server.API.Host, cfg.Server.API.AdminPort)
+		adminSrv = &http.Server{
+			Addr:         adminAddr,
+			Handler:      adminRouter,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		}
+
+		go func() {
+			logger.Info("Admin server listening", zap.String("addr", adminAddr))
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("Failed to start admin server", zap.Error(err))
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	logger.Info("Shutting down server...")
+	cancel()
 
 	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
+	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Admin server forced to shutdown", zap.Error(err))
+		}
+	}
 
 	logger.Info("Server exited")
 }
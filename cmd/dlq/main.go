@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/yourusername/reco-engine/internal/processor"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"go.uber.org/zap"
+)
+
+// dlqHeaders are the error metadata headers added by the processor
+var dlqHeaders = map[string]bool{
+	processor.HeaderError:             true,
+	processor.HeaderAttempts:          true,
+	processor.HeaderFailedAt:          true,
+	processor.HeaderOriginalTopic:     true,
+	processor.HeaderOriginalPartition: true,
+	processor.HeaderOriginalOffset:    true,
+}
+
+// dlqRecord is the printed form of a dead-letter message
+type dlqRecord struct {
+	Partition int               `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Key       string            `json:"key"`
+	Time      time.Time         `json:"time"`
+	Headers   map[string]string `json:"headers"`
+	Payload   json.RawMessage   `json:"payload,omitempty"`
+	Raw       string            `json:"raw,omitempty"`
+}
+
+func main() {
+	configPath := flag.String("config", "", "path to config file")
+	replay := flag.Bool("replay", false, "republish messages to their original topic and commit them")
+	limit := flag.Int("limit", 100, "maximum number of messages to read")
+	wait := flag.Duration("wait", 5*time.Second, "stop when no message arrives within this duration")
+	group := flag.String("group", "", "consumer group for the dead-letter topic (default: <consumer_group>-dlq)")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load config: %v", err))
+	}
+
+	// Initialize logger
+	if err := logger.Init(cfg.Observability.Logging.Level, cfg.Observability.Logging.Format); err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer logger.Sync()
+
+	if cfg.Kafka.Topics.DeadLetter == "" {
+		logger.Fatal("No dead-letter topic configured (kafka.topics.dead_letter)")
+	}
+
+	groupID := *group
+	if groupID == "" {
+		groupID = cfg.Kafka.ConsumerGroup + "-dlq"
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     cfg.Kafka.Brokers,
+		Topic:       cfg.Kafka.Topics.DeadLetter,
+		GroupID:     groupID,
+		MinBytes:    1,
+		MaxBytes:    10e6, // 10MB
+		StartOffset: kafka.FirstOffset,
+	})
+	defer reader.Close()
+
+	var writer *kafka.Writer
+	if *replay {
+		writer = &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Kafka.Brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		}
+		defer writer.Close()
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	encoder := json.NewEncoder(os.Stdout)
+	read, replayed := 0, 0
+	for read < *limit {
+		fetchCtx, fetchCancel := context.WithTimeout(ctx, *wait)
+		msg, err := reader.FetchMessage(fetchCtx)
+		fetchCancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+				break
+			}
+			logger.Fatal("Failed to fetch message", zap.Error(err))
+		}
+		read++
+
+		if err := encoder.Encode(toRecord(msg)); err != nil {
+			logger.Fatal("Failed to write message", zap.Error(err))
+		}
+
+		if writer == nil {
+			continue
+		}
+
+		target := replayMessage(msg, cfg.Kafka.Topics.Events)
+		if err := writer.WriteMessages(ctx, target); err != nil {
+			logger.Fatal("Failed to replay message",
+				zap.Error(err),
+				zap.Int("partition", msg.Partition),
+				zap.Int64("offset", msg.Offset))
+		}
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			logger.Fatal("Failed to commit replayed message", zap.Error(err))
+		}
+		replayed++
+	}
+
+	logger.Info("Dead-letter scan finished",
+		zap.String("topic", cfg.Kafka.Topics.DeadLetter),
+		zap.Int("read", read),
+		zap.Int("replayed", replayed))
+}
+
+func toRecord(msg kafka.Message) dlqRecord {
+	record := dlqRecord{
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       string(msg.Key),
+		Time:      msg.Time,
+		Headers:   make(map[string]string, len(msg.Headers)),
+	}
+	for _, h := range msg.Headers {
+		record.Headers[h.Key] = string(h.Value)
+	}
+
+	if json.Valid(msg.Value) {
+		record.Payload = msg.Value
+	} else {
+		record.Raw = string(msg.Value)
+	}
+	return record
+}
+
+// replayMessage builds the message to republish: the original payload and
+// key, sent to the original topic, without the dead-letter headers
+func replayMessage(msg kafka.Message, defaultTopic string) kafka.Message {
+	out := kafka.Message{
+		Topic: defaultTopic,
+		Key:   msg.Key,
+		Value: msg.Value,
+	}
+
+	for _, h := range msg.Headers {
+		if h.Key == processor.HeaderOriginalTopic && len(h.Value) > 0 {
+			out.Topic = string(h.Value)
+			continue
+		}
+		if dlqHeaders[h.Key] {
+			continue
+		}
+		out.Headers = append(out.Headers, h)
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/yourusername/reco-engine/internal/processor"
+)
+
+func TestReplayMessage_StripsHeadersAndRoutesToOriginalTopic(t *testing.T) {
+	msg := kafka.Message{
+		Topic:     "events.dlq",
+		Partition: 1,
+		Offset:    9,
+		Key:       []byte("7"),
+		Value:     []byte(`{"user_id":7}`),
+		Headers: []kafka.Header{
+			{Key: "traceparent", Value: []byte("00-abc")},
+			{Key: processor.HeaderError, Value: []byte("boom")},
+			{Key: processor.HeaderAttempts, Value: []byte("3")},
+			{Key: processor.HeaderFailedAt, Value: []byte("2025-03-01T00:00:00Z")},
+			{Key: processor.HeaderOriginalTopic, Value: []byte("events.v2")},
+			{Key: processor.HeaderOriginalPartition, Value: []byte("4")},
+			{Key: processor.HeaderOriginalOffset, Value: []byte("42")},
+		},
+	}
+
+	out := replayMessage(msg, "events")
+	assert.Equal(t, kafka.Message{
+		Topic:   "events.v2",
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: []kafka.Header{{Key: "traceparent", Value: []byte("00-abc")}},
+	}, out)
+
+	// Without the original topic the configured events topic is used
+	msg.Headers = msg.Headers[:2]
+	out = replayMessage(msg, "events")
+	assert.Equal(t, "events", out.Topic)
+	assert.Equal(t, []kafka.Header{{Key: "traceparent", Value: []byte("00-abc")}}, out.Headers)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/reco-engine/internal/evaluation"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"go.uber.org/zap"
+)
+
+func main() {
+	configPath := flag.String("config", "", "path to config file")
+	splitFlag := flag.String("split", "", "split time, RFC 3339 (default: now minus -test-window)")
+	trainWindow := flag.Duration("train-window", 30*24*time.Hour, "replay events logged within this duration before the split")
+	testWindow := flag.Duration("test-window", 7*24*time.Hour, "hold out events logged within this duration after the split")
+	k := flag.Int("k", 10, "recommendations per user")
+	targets := flag.String("targets", "", "comma-separated event types that count as relevant (default: all)")
+	maxUsers := flag.Int("max-users", 0, "evaluate at most this many held-out users (0 for all)")
+	save := flag.String("save", "", "register the results as a model with this name")
+	loadModels := flag.Bool("models", true, "serve the offline models' served versions, reading their KNN lists and user factors from Redis")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load config: %v", err))
+	}
+
+	// Initialize logger
+	if err := logger.Init(cfg.Observability.Logging.Level, cfg.Observability.Logging.Format); err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer logger.Sync()
+
+	start := time.Now().UTC()
+	split := start.Add(-*testWindow)
+	if *splitFlag != "" {
+		split, err = time.Parse(time.RFC3339, *splitFlag)
+		if err != nil {
+			logger.Fatal("Invalid split time", zap.Error(err))
+		}
+	}
+	opts := evaluation.Options{
+		SplitAt:     split,
+		TrainWindow: *trainWindow,
+		TestWindow:  *testWindow,
+		K:           *k,
+		MaxUsers:    *maxUsers,
+	}
+	if *targets != "" {
+		opts.Targets = strings.Split(strings.ToUpper(*targets), ",")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	pgStore, err := store.NewPostgresStore(cfg.Postgres)
+	if err != nil {
+		logger.Fatal("Failed to connect to PostgreSQL", zap.Error(err))
+	}
+	defer pgStore.Close()
+
+	// The models' KNN lists and user factors are only read
+	var modelData store.RecoSignalStore
+	if *loadModels {
+		redisStore, err := store.NewRedisStore(cfg.Redis)
+		if err != nil {
+			logger.Fatal("Failed to connect to Redis", zap.Error(err))
+		}
+		defer redisStore.Close()
+		modelData = redisStore
+	}
+
+	logger.Info("Evaluating", zap.Time("split", split), zap.Bool("models", *loadModels))
+	report, err := evaluation.Run(ctx, cfg, pgStore, modelData, opts)
+	if err != nil {
+		logger.Fatal("Evaluation failed", zap.Error(err))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		logger.Fatal("Failed to write report", zap.Error(err))
+	}
+
+	if *save == "" {
+		return
+	}
+	weights := cfg.Recommendation.Weights
+	record := &models.Model{
+		ModelName: *save,
+		Version:   start.Format("20060102T150405Z"),
+		ModelType: "evaluation",
+		Metrics:   report.Metrics(),
+		Config: map[string]interface{}{
+			"split":        split.Format(time.RFC3339),
+			"train_window": trainWindow.String(),
+			"test_window":  testWindow.String(),
+			"k":            opts.K,
+			"targets":      opts.Targets,
+			"max_users":    opts.MaxUsers,
+			"weights": map[string]float64{
+				"coview":     weights.Coview,
+				"embedding":  weights.Embedding,
+				"popularity": weights.Popularity,
+				"recency":    weights.Recency,
+				"transition": weights.Transition,
+				"cf":         weights.CF,
+			},
+			"models": report.Models,
+		},
+	}
+	if err := pgStore.InsertModel(ctx, record); err != nil {
+		logger.Fatal("Failed to save results", zap.Error(err))
+	}
+	logger.Info("Saved evaluation results",
+		zap.String("model", record.ModelName),
+		zap.String("version", record.Version),
+		zap.Int64("model_id", record.ID))
+}
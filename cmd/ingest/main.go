@@ -11,10 +11,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/yourusername/reco-engine/internal/health"
 	"github.com/yourusername/reco-engine/internal/ingest"
 	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/stream"
 	"github.com/yourusername/reco-engine/internal/util/config"
 	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 )
 
@@ -33,6 +37,19 @@ func main() {
 
 	logger.Info("Starting Event Ingest Service")
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Observability.Tracing, "reco-ingest")
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", zap.Error(err))
+		}
+	}()
+
 	// Initialize PostgreSQL
 	pgStore, err := store.NewPostgresStore(cfg.Postgres)
 	if err != nil {
@@ -40,22 +57,43 @@ func main() {
 	}
 	defer pgStore.Close()
 
+	// Initialize Redis
+	redisStore, err := store.NewRedisStore(cfg.Redis)
+	if err != nil {
+		logger.Fatal("Failed to connect to Redis", zap.Error(err))
+	}
+	defer redisStore.Close()
+
 	// Initialize service
-	svc := ingest.NewService(cfg, pgStore)
+	svc := ingest.NewService(cfg, ingest.NewKafkaWriter(cfg), pgStore, redisStore)
 	defer svc.Close()
 
 	// Initialize handler
 	handler := ingest.NewHandler(svc)
 
+	// Readiness checks. Ingest cannot accept events without Kafka; dedup
+	// fails open without Redis and event archiving is best effort.
+	checker := health.NewChecker(cfg.Observability.Health.CheckTimeout)
+	checker.Register("kafka", func(ctx context.Context) error {
+		return stream.Ping(ctx, cfg.Kafka.Brokers, cfg.Kafka.Topics.Events)
+	}, true)
+	checker.Register("redis", redisStore.Ping, false)
+	checker.Register("postgres", pgStore.Ping, false)
+
 	// Setup Gin router
 	if cfg.Observability.Logging.Format == "json" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	router := gin.Default()
+	router.Use(otelgin.Middleware("reco-ingest", otelgin.WithFilter(func(r *http.Request) bool {
+		return !health.IsProbe(r)
+	})))
 
 	// Routes
 	router.GET("/health", handler.HandleHealth)
+	checker.RegisterRoutes(router)
 	router.POST("/events", handler.HandleIngestEvent)
+	router.POST("/events/batch", handler.HandleIngestBatch)
 
 	// Metrics endpoint
 	if cfg.Observability.Metrics.Enabled {
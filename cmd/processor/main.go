@@ -3,14 +3,21 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/yourusername/reco-engine/internal/health"
 	"github.com/yourusername/reco-engine/internal/processor"
 	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/stream"
 	"github.com/yourusername/reco-engine/internal/util/config"
 	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/tracing"
 	"go.uber.org/zap"
 )
 
@@ -29,6 +36,19 @@ func main() {
 
 	logger.Info("Starting Stream Processor Service")
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Observability.Tracing, "reco-processor")
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", zap.Error(err))
+		}
+	}()
+
 	// Initialize Redis
 	redisStore, err := store.NewRedisStore(cfg.Redis)
 	if err != nil {
@@ -36,20 +56,67 @@ func main() {
 	}
 	defer redisStore.Close()
 
+	// Initialize PostgreSQL
+	pgStore, err := store.NewPostgresStore(cfg.Postgres)
+	if err != nil {
+		logger.Fatal("Failed to connect to PostgreSQL", zap.Error(err))
+	}
+	defer pgStore.Close()
+
 	// Initialize service
-	svc := processor.NewService(cfg, redisStore)
+	// A nil publisher disables dead-lettering; keep it an untyped nil so the
+	// service sees a nil interface rather than a nil *kafka.Writer.
+	var dlqPublisher stream.EventPublisher
+	if cfg.Kafka.Topics.DeadLetter != "" {
+		dlqPublisher = processor.NewDeadLetterWriter(cfg)
+	}
+
+	svc := processor.NewService(cfg, processor.NewKafkaReader(cfg), dlqPublisher, redisStore, pgStore)
 	defer svc.Close()
 
+	// Readiness checks. Category lookups in PostgreSQL are best effort.
+	checker := health.NewChecker(cfg.Observability.Health.CheckTimeout)
+	checker.Register("kafka", func(ctx context.Context) error {
+		return stream.Ping(ctx, cfg.Kafka.Brokers, cfg.Kafka.Topics.Events)
+	}, true)
+	checker.Register("redis", redisStore.Ping, true)
+	checker.Register("postgres", pgStore.Ping, false)
+
+	// Health and metrics server
+	if cfg.Observability.Logging.Format == "json" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	router := gin.New()
+	router.Use(gin.Recovery())
+	checker.RegisterRoutes(router)
+	if cfg.Observability.Metrics.Enabled {
+		router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	}
+
+	addr := fmt.Sprintf(":%d", cfg.Observability.Metrics.Port)
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      router,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logger.Info("Health and metrics server listening", zap.String("addr", addr))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Failed to start health and metrics server", zap.Error(err))
+		}
+	}()
+
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start processing in goroutine
-	errCh := make(chan error, 1)
+	// Start processing in goroutine. done receives Start's result once the
+	// workers have drained and committed their offsets.
+	done := make(chan error, 1)
 	go func() {
-		if err := svc.Start(ctx); err != nil {
-			errCh <- err
-		}
+		done <- svc.Start(ctx)
 	}()
 
 	// Wait for interrupt signal
@@ -60,10 +127,21 @@ func main() {
 	case <-quit:
 		logger.Info("Received shutdown signal")
 		cancel()
-	case err := <-errCh:
-		logger.Error("Processor error", zap.Error(err))
+		if err := <-done; err != nil {
+			logger.Error("Processor error", zap.Error(err))
+		}
+	case err := <-done:
+		if err != nil {
+			logger.Error("Processor error", zap.Error(err))
+		}
 		cancel()
 	}
 
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Health and metrics server forced to shutdown", zap.Error(err))
+	}
+
 	logger.Info("Processor exited")
 }
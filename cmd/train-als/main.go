@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/reco-engine/internal/als"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"go.uber.org/zap"
+)
+
+func main() {
+	defaults := als.DefaultConfig()
+	configPath := flag.String("config", "", "path to config file")
+	lookback := flag.Duration("lookback", 180*24*time.Hour, "train on events logged within this duration")
+	modelName := flag.String("model", "", "model name to register (default: recommendation.cf.model_name)")
+	version := flag.String("version", "", "model version to register (default: the start time)")
+	userTTL := flag.Duration("user-ttl", 30*24*time.Hour, "how long user factors are kept in Redis")
+	activate := flag.Bool("activate", false, "make the new version the one served once it is written")
+	factors := flag.Int("factors", defaults.Factors, "latent factors")
+	iterations := flag.Int("iterations", defaults.Iterations, "ALS iterations")
+	regularization := flag.Float64("reg", defaults.Regularization, "L2 regularization")
+	alpha := flag.Float64("alpha", defaults.Alpha, "confidence per unit of event weight")
+	workers := flag.Int("workers", defaults.Workers, "goroutines solving in parallel")
+	seed := flag.Int64("seed", defaults.Seed, "random seed")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load config: %v", err))
+	}
+
+	// Initialize logger
+	if err := logger.Init(cfg.Observability.Logging.Level, cfg.Observability.Logging.Format); err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer logger.Sync()
+
+	start := time.Now().UTC()
+	if *modelName == "" {
+		*modelName = cfg.Recommendation.CF.ModelName
+	}
+	if *modelName == "" {
+		*modelName = "als"
+	}
+	if *version == "" {
+		*version = start.Format("20060102T150405Z")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	pgStore, err := store.NewPostgresStore(cfg.Postgres)
+	if err != nil {
+		logger.Fatal("Failed to connect to PostgreSQL", zap.Error(err))
+	}
+	defer pgStore.Close()
+
+	redisStore, err := store.NewRedisStore(cfg.Redis)
+	if err != nil {
+		logger.Fatal("Failed to connect to Redis", zap.Error(err))
+	}
+	defer redisStore.Close()
+
+	// Build the user x item matrix, weighting events like the processor
+	since := start.Add(-*lookback)
+	matrix := als.NewMatrix()
+	events := 0
+	err = pgStore.ScanEvents(ctx, since, start, func(event *models.Event) error {
+		if event.UserID == 0 || event.ItemID == 0 {
+			return nil
+		}
+		matrix.Add(event.UserID, event.ItemID, cfg.EventWeights.Weight(event.EventType))
+		events++
+		return nil
+	})
+	if err != nil {
+		logger.Fatal("Failed to read events", zap.Error(err))
+	}
+	logger.Info("Built interaction matrix",
+		zap.Time("since", since),
+		zap.Int("events", events),
+		zap.Int("interactions", matrix.Len()))
+
+	// Train
+	trainCfg := als.Config{
+		Factors:        *factors,
+		Iterations:     *iterations,
+		Regularization: *regularization,
+		Alpha:          *alpha,
+		Workers:        *workers,
+		Seed:           *seed,
+	}
+	trainStart := time.Now()
+	model, err := als.Train(matrix, trainCfg)
+	if err != nil {
+		logger.Fatal("Failed to train factors", zap.Error(err))
+	}
+	trainDuration := time.Since(trainStart)
+	logger.Info("Trained factors",
+		zap.Int("users", len(model.UserFactors)),
+		zap.Int("items", len(model.ItemFactors)),
+		zap.Float64("loss", model.Loss),
+		zap.Duration("duration", trainDuration))
+
+	// Register the model as pending so it is not served while its factors are
+	// written. It cannot be activated once its user factors expire.
+	expiresAt := time.Now().Add(*userTTL)
+	record := &models.Model{
+		ModelName: *modelName,
+		Version:   *version,
+		ModelType: "als",
+		Status:    models.ModelStatusPending,
+		ExpiresAt: &expiresAt,
+		Metrics: map[string]interface{}{
+			"events":           events,
+			"interactions":     model.Interactions,
+			"users":            len(model.UserFactors),
+			"items":            len(model.ItemFactors),
+			"loss":             model.Loss,
+			"training_seconds": trainDuration.Seconds(),
+		},
+		Config: map[string]interface{}{
+			"lookback":       lookback.String(),
+			"factors":        trainCfg.Factors,
+			"iterations":     trainCfg.Iterations,
+			"regularization": trainCfg.Regularization,
+			"alpha":          trainCfg.Alpha,
+			"seed":           trainCfg.Seed,
+			"event_weights": map[string]float64{
+				models.EventTypeView:     cfg.EventWeights.View,
+				models.EventTypeClick:    cfg.EventWeights.Click,
+				models.EventTypeCart:     cfg.EventWeights.Cart,
+				models.EventTypePurchase: cfg.EventWeights.Purchase,
+			},
+		},
+	}
+	if err := pgStore.InsertModel(ctx, record); err != nil {
+		logger.Fatal("Failed to register model", zap.Error(err))
+	}
+
+	saved, err := pgStore.SaveItemEmbeddings(ctx, record.ID, model.ItemFactors)
+	if err != nil {
+		logger.Fatal("Failed to save item factors", zap.Error(err), zap.Int64("model_id", record.ID))
+	}
+	if skipped := int64(len(model.ItemFactors)) - saved; skipped > 0 {
+		logger.Warn("Skipped factors of items missing from the catalog", zap.Int64("skipped", skipped))
+	}
+
+	if err := redisStore.SetUserFactors(ctx, record.ID, model.UserFactors, *userTTL); err != nil {
+		logger.Fatal("Failed to save user factors", zap.Error(err), zap.Int64("model_id", record.ID))
+	}
+
+	if err := pgStore.CompleteModel(ctx, record.ID); err != nil {
+		logger.Fatal("Failed to complete model", zap.Error(err), zap.Int64("model_id", record.ID))
+	}
+	if *activate {
+		if err := pgStore.ActivateModel(ctx, record.ID); err != nil {
+			logger.Fatal("Failed to activate model", zap.Error(err), zap.Int64("model_id", record.ID))
+		}
+	}
+
+	logger.Info("ALS training finished",
+		zap.String("model", record.ModelName),
+		zap.String("version", record.Version),
+		zap.Int64("model_id", record.ID),
+		zap.Int64("saved_items", saved),
+		zap.Int("saved_users", len(model.UserFactors)),
+		zap.Bool("activated", *activate),
+		zap.Duration("duration", time.Since(start)))
+}
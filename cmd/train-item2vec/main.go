@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/reco-engine/internal/ann"
+	"github.com/yourusername/reco-engine/internal/item2vec"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"go.uber.org/zap"
+)
+
+func main() {
+	defaults := item2vec.DefaultConfig()
+	configPath := flag.String("config", "", "path to config file")
+	lookback := flag.Duration("lookback", 90*24*time.Hour, "train on events logged within this duration")
+	sessionGap := flag.Duration("session-gap", 30*time.Minute, "cut a session where events are further apart than this")
+	modelName := flag.String("model", "", "model name to register (default: recommendation.ann.model_name)")
+	version := flag.String("version", "", "model version to register (default: the start time)")
+	knn := flag.Int("knn", 100, "neighbours to store per item in item:knn")
+	activate := flag.Bool("activate", false, "make the new version the one served once it is written")
+	dim := flag.Int("dim", defaults.Dim, "embedding dimension")
+	window := flag.Int("window", defaults.Window, "context window")
+	epochs := flag.Int("epochs", defaults.Epochs, "training epochs")
+	negative := flag.Int("negative", defaults.Negative, "negative samples per pair")
+	minCount := flag.Int("min-count", defaults.MinCount, "drop items seen fewer times")
+	learningRate := flag.Float64("lr", defaults.LearningRate, "starting learning rate")
+	sample := flag.Float64("sample", defaults.Sample, "subsampling threshold for frequent items, 0 to disable")
+	seed := flag.Int64("seed", defaults.Seed, "random seed")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load config: %v", err))
+	}
+
+	// Initialize logger
+	if err := logger.Init(cfg.Observability.Logging.Level, cfg.Observability.Logging.Format); err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer logger.Sync()
+
+	start := time.Now().UTC()
+	if *modelName == "" {
+		*modelName = cfg.Recommendation.ANN.ModelName
+	}
+	if *modelName == "" {
+		*modelName = "item2vec"
+	}
+	if *version == "" {
+		*version = start.Format("20060102T150405Z")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	pgStore, err := store.NewPostgresStore(cfg.Postgres)
+	if err != nil {
+		logger.Fatal("Failed to connect to PostgreSQL", zap.Error(err))
+	}
+	defer pgStore.Close()
+
+	redisStore, err := store.NewRedisStore(cfg.Redis)
+	if err != nil {
+		logger.Fatal("Failed to connect to Redis", zap.Error(err))
+	}
+	defer redisStore.Close()
+
+	// Read sessions
+	since := start.Add(-*lookback)
+	builder := item2vec.NewSequenceBuilder(*sessionGap)
+	events := 0
+	err = pgStore.ScanEvents(ctx, since, start, func(event *models.Event) error {
+		builder.Add(event)
+		events++
+		return nil
+	})
+	if err != nil {
+		logger.Fatal("Failed to read events", zap.Error(err))
+	}
+	sequences := builder.Sequences()
+	logger.Info("Read sessions",
+		zap.Time("since", since),
+		zap.Int("events", events),
+		zap.Int("sequences", len(sequences)))
+
+	// Train
+	trainCfg := item2vec.Config{
+		Dim:          *dim,
+		Window:       *window,
+		Epochs:       *epochs,
+		Negative:     *negative,
+		MinCount:     *minCount,
+		LearningRate: *learningRate,
+		Sample:       *sample,
+		Seed:         *seed,
+	}
+	trainStart := time.Now()
+	model, err := item2vec.Train(sequences, trainCfg)
+	if err != nil {
+		logger.Fatal("Failed to train embeddings", zap.Error(err))
+	}
+	trainDuration := time.Since(trainStart)
+	logger.Info("Trained embeddings",
+		zap.Int("items", len(model.Embeddings)),
+		zap.Int64("pairs", model.Pairs),
+		zap.Float64("loss", model.Loss),
+		zap.Duration("duration", trainDuration))
+
+	// Register the model as pending so it is not served while its embeddings
+	// and neighbour lists are written. Versions with neighbour lists cannot be
+	// activated once the lists expire.
+	var expiresAt *time.Time
+	if *knn > 0 {
+		t := time.Now().Add(store.KNNTTL)
+		expiresAt = &t
+	}
+	record := &models.Model{
+		ModelName: *modelName,
+		Version:   *version,
+		ModelType: "item2vec",
+		Status:    models.ModelStatusPending,
+		ExpiresAt: expiresAt,
+		Metrics: map[string]interface{}{
+			"events":           events,
+			"sequences":        model.Sequences,
+			"tokens":           model.Tokens,
+			"items":            len(model.Embeddings),
+			"pairs":            model.Pairs,
+			"loss":             model.Loss,
+			"training_seconds": trainDuration.Seconds(),
+		},
+		Config: map[string]interface{}{
+			"lookback":      lookback.String(),
+			"session_gap":   sessionGap.String(),
+			"dim":           trainCfg.Dim,
+			"window":        trainCfg.Window,
+			"epochs":        trainCfg.Epochs,
+			"negative":      trainCfg.Negative,
+			"min_count":     trainCfg.MinCount,
+			"learning_rate": trainCfg.LearningRate,
+			"sample":        trainCfg.Sample,
+			"seed":          trainCfg.Seed,
+		},
+	}
+	if err := pgStore.InsertModel(ctx, record); err != nil {
+		logger.Fatal("Failed to register model", zap.Error(err))
+	}
+
+	saved, err := pgStore.SaveItemEmbeddings(ctx, record.ID, model.Embeddings)
+	if err != nil {
+		logger.Fatal("Failed to save embeddings", zap.Error(err), zap.Int64("model_id", record.ID))
+	}
+	if skipped := int64(len(model.Embeddings)) - saved; skipped > 0 {
+		logger.Warn("Skipped embeddings of items missing from the catalog", zap.Int64("skipped", skipped))
+	}
+
+	// Precompute neighbour lists for serving without the in-process index
+	if *knn > 0 {
+		if err := writeKNN(ctx, redisStore, record.ID, model.Embeddings, *knn, cfg.Recommendation.ANN); err != nil {
+			logger.Fatal("Failed to write neighbour lists", zap.Error(err))
+		}
+	}
+
+	if err := pgStore.CompleteModel(ctx, record.ID); err != nil {
+		logger.Fatal("Failed to complete model", zap.Error(err), zap.Int64("model_id", record.ID))
+	}
+	if *activate {
+		if err := pgStore.ActivateModel(ctx, record.ID); err != nil {
+			logger.Fatal("Failed to activate model", zap.Error(err), zap.Int64("model_id", record.ID))
+		}
+	}
+
+	logger.Info("Item2vec training finished",
+		zap.String("model", record.ModelName),
+		zap.String("version", record.Version),
+		zap.Int64("model_id", record.ID),
+		zap.Int64("saved", saved),
+		zap.Bool("activated", *activate),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// writeKNN stores the k nearest neighbours of every item under the model,
+// found with the same index the API serves from
+func writeKNN(ctx context.Context, redisStore *store.RedisStore, modelID int64, embeddings map[int64][]float64, k int, annCfg config.ANNConfig) error {
+	itemIDs := make([]int64, 0, len(embeddings))
+	for itemID := range embeddings {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i] < itemIDs[j] })
+
+	index := ann.New(len(embeddings[itemIDs[0]]), ann.Config{
+		M:              annCfg.M,
+		EfConstruction: annCfg.EfConstruction,
+		EfSearch:       max(annCfg.EfSearch, 2*k),
+		Seed:           1,
+	})
+	for _, itemID := range itemIDs {
+		if err := index.Add(itemID, embeddings[itemID]); err != nil {
+			logger.Debug("Skipping item embedding", zap.Error(err))
+		}
+	}
+
+	lists := make(map[int64][]int64, len(itemIDs))
+	for _, itemID := range itemIDs {
+		results, ok := index.SearchItem(itemID, k)
+		if !ok {
+			continue
+		}
+		neighbours := make([]int64, len(results))
+		for i, r := range results {
+			neighbours[i] = r.ID
+		}
+		lists[itemID] = neighbours
+	}
+	if err := redisStore.SetItemKNNs(ctx, modelID, lists); err != nil {
+		return fmt.Errorf("failed to set neighbours: %w", err)
+	}
+
+	logger.Info("Wrote neighbour lists", zap.Int("items", len(lists)), zap.Int("k", k))
+	return nil
+}
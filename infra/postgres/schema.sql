@@ -30,6 +30,7 @@ CREATE INDEX idx_users_external_id ON users(external_id);
 -- Events table (append-only for raw events)
 CREATE TABLE IF NOT EXISTS events (
     id BIGSERIAL PRIMARY KEY,
+    event_id TEXT, -- client-supplied idempotency key
     user_id BIGINT,
     item_id BIGINT,
     event_type TEXT NOT NULL, -- VIEW, CLICK, CART, PURCHASE
@@ -43,6 +44,7 @@ CREATE INDEX idx_events_item_id ON events(item_id);
 CREATE INDEX idx_events_timestamp ON events(timestamp DESC);
 CREATE INDEX idx_events_session_id ON events(session_id);
 CREATE INDEX idx_events_type ON events(event_type);
+CREATE INDEX idx_events_event_id ON events(event_id);
 
 -- Models metadata table
 CREATE TABLE IF NOT EXISTS models (
@@ -53,22 +55,27 @@ CREATE TABLE IF NOT EXISTS models (
     metrics JSONB,
     config JSONB,
     created_at TIMESTAMP DEFAULT now(),
+    status TEXT NOT NULL DEFAULT 'complete', -- pending while the trainer writes the model's data
+    is_active BOOLEAN NOT NULL DEFAULT false, -- the version served for model_name
+    activated_at TIMESTAMP, -- when the version was last promoted
+    expires_at TIMESTAMP, -- when the version's Redis data (KNN lists, user factors) expires
     UNIQUE(model_name, version)
 );
 
 CREATE INDEX idx_models_name ON models(model_name);
+CREATE UNIQUE INDEX idx_models_active ON models(model_name) WHERE is_active;
 
--- Item embeddings table (optional, if not using external vector DB)
+-- Item embeddings table (optional, if not using external vector DB).
+-- Every model version keeps its own vectors, so serving can switch between versions.
 CREATE TABLE IF NOT EXISTS item_embeddings (
-    item_id BIGINT PRIMARY KEY REFERENCES items(id),
-    model_id BIGINT REFERENCES models(id),
+    model_id BIGINT NOT NULL REFERENCES models(id),
+    item_id BIGINT NOT NULL REFERENCES items(id),
     embedding FLOAT8[],
     created_at TIMESTAMP DEFAULT now(),
-    updated_at TIMESTAMP DEFAULT now()
+    updated_at TIMESTAMP DEFAULT now(),
+    PRIMARY KEY (model_id, item_id)
 );
 
-CREATE INDEX idx_item_embeddings_model ON item_embeddings(model_id);
-
 -- Seed some sample data
 INSERT INTO items (sku, title, category, price, stock) VALUES
 ('SKU001', 'Laptop Gaming ASUS ROG', 'electronics', 15000000, 10),
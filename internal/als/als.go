@@ -0,0 +1,305 @@
+// Package als factorises implicit feedback with alternating least squares
+// (Hu, Koren & Volinsky, 2008). Every observed user-item weight r becomes a
+// preference of 1 with confidence 1 + alpha*r; unobserved pairs have
+// preference 0 and confidence 1.
+package als
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// ErrNoInteractions is returned when the matrix is empty
+var ErrNoInteractions = errors.New("no interactions")
+
+// Config holds the training parameters
+type Config struct {
+	Factors        int     // latent dimension
+	Iterations     int     // alternating passes over users and items
+	Regularization float64 // L2 penalty on the factors
+	Alpha          float64 // confidence per unit of interaction weight
+	Workers        int     // goroutines solving rows in parallel
+	Seed           int64
+}
+
+// DefaultConfig returns parameters that work well for event weights in the
+// range of the configured event_weights
+func DefaultConfig() Config {
+	return Config{
+		Factors:        32,
+		Iterations:     10,
+		Regularization: 0.1,
+		Alpha:          1,
+		Workers:        4,
+		Seed:           1,
+	}
+}
+
+// Matrix accumulates user-item interaction weights
+type Matrix struct {
+	weights map[int64]map[int64]float64
+	nnz     int
+}
+
+// NewMatrix creates an empty interaction matrix
+func NewMatrix() *Matrix {
+	return &Matrix{weights: make(map[int64]map[int64]float64)}
+}
+
+// Add adds weight to the interaction of a user with an item
+func (m *Matrix) Add(userID, itemID int64, weight float64) {
+	row := m.weights[userID]
+	if row == nil {
+		row = make(map[int64]float64)
+		m.weights[userID] = row
+	}
+	if _, ok := row[itemID]; !ok {
+		m.nnz++
+	}
+	row[itemID] += weight
+}
+
+// Len returns the number of distinct user-item interactions
+func (m *Matrix) Len() int {
+	return m.nnz
+}
+
+// Model holds trained factors and training statistics. A user's predicted
+// preference for an item is the dot product of their factors.
+type Model struct {
+	UserFactors  map[int64][]float64
+	ItemFactors  map[int64][]float64
+	Interactions int
+	Loss         float64 // weighted squared error plus penalty, per interaction
+}
+
+// Train factorises the matrix. Rows are solved independently, so the result
+// does not depend on the number of workers.
+func Train(m *Matrix, cfg Config) (*Model, error) {
+	defaults := DefaultConfig()
+	if cfg.Factors < 1 {
+		cfg.Factors = defaults.Factors
+	}
+	if cfg.Iterations < 1 {
+		cfg.Iterations = defaults.Iterations
+	}
+	if cfg.Regularization <= 0 {
+		cfg.Regularization = defaults.Regularization
+	}
+	if cfg.Alpha <= 0 {
+		cfg.Alpha = defaults.Alpha
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if m.nnz == 0 {
+		return nil, ErrNoInteractions
+	}
+
+	users, items, byUser, byItem := m.compress(cfg.Alpha)
+	k := cfg.Factors
+
+	// Users are solved first, so only item factors need a starting point
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	x := make([]float64, len(users)*k)
+	y := make([]float64, len(items)*k)
+	for i := range y {
+		y[i] = rng.NormFloat64() * 0.01
+	}
+
+	for iter := 0; iter < cfg.Iterations; iter++ {
+		solveRows(x, y, byUser, cfg)
+		solveRows(y, x, byItem, cfg)
+	}
+
+	return &Model{
+		UserFactors:  unflatten(users, x, k),
+		ItemFactors:  unflatten(items, y, k),
+		Interactions: m.nnz,
+		Loss:         loss(x, y, byUser, cfg) / float64(m.nnz),
+	}, nil
+}
+
+// entry is an observed cell of a row: the column and its confidence
+type entry struct {
+	col        int32
+	confidence float64
+}
+
+// compress maps IDs to dense indexes in ID order and returns the observed
+// cells by user and by item
+func (m *Matrix) compress(alpha float64) ([]int64, []int64, [][]entry, [][]entry) {
+	users := make([]int64, 0, len(m.weights))
+	itemSet := make(map[int64]struct{})
+	for userID, row := range m.weights {
+		users = append(users, userID)
+		for itemID := range row {
+			itemSet[itemID] = struct{}{}
+		}
+	}
+	items := make([]int64, 0, len(itemSet))
+	for itemID := range itemSet {
+		items = append(items, itemID)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i] < users[j] })
+	sort.Slice(items, func(i, j int) bool { return items[i] < items[j] })
+
+	itemIndex := make(map[int64]int32, len(items))
+	for i, itemID := range items {
+		itemIndex[itemID] = int32(i)
+	}
+
+	byUser := make([][]entry, len(users))
+	byItem := make([][]entry, len(items))
+	for u, userID := range users {
+		for itemID, weight := range m.weights[userID] {
+			i := itemIndex[itemID]
+			c := 1 + alpha*weight
+			byUser[u] = append(byUser[u], entry{i, c})
+			byItem[i] = append(byItem[i], entry{int32(u), c})
+		}
+	}
+	// Summation order decides the last bits of the factors
+	for _, rows := range [][][]entry{byUser, byItem} {
+		for _, row := range rows {
+			sort.Slice(row, func(a, b int) bool { return row[a].col < row[b].col })
+		}
+	}
+	return users, items, byUser, byItem
+}
+
+// solveRows recomputes every row of out with the other side fixed:
+// out_r = (FᵀF + Fᵀ(Cʳ - I)F + λI)⁻¹ FᵀCʳp(r)
+func solveRows(out, fixed []float64, rows [][]entry, cfg Config) {
+	k := cfg.Factors
+	gram := make([]float64, k*k)
+	for r := 0; r < len(fixed)/k; r++ {
+		f := fixed[r*k : (r+1)*k]
+		for a := 0; a < k; a++ {
+			for b := 0; b < k; b++ {
+				gram[a*k+b] += f[a] * f[b]
+			}
+		}
+	}
+	for a := 0; a < k; a++ {
+		gram[a*k+a] += cfg.Regularization
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			a := make([]float64, k*k)
+			b := make([]float64, k)
+			for r := w; r < len(rows); r += cfg.Workers {
+				copy(a, gram)
+				for i := range b {
+					b[i] = 0
+				}
+				for _, e := range rows[r] {
+					f := fixed[int(e.col)*k : int(e.col+1)*k]
+					for i := 0; i < k; i++ {
+						b[i] += e.confidence * f[i]
+						ci := (e.confidence - 1) * f[i]
+						for j := 0; j < k; j++ {
+							a[i*k+j] += ci * f[j]
+						}
+					}
+				}
+				choleskySolve(a, b, k)
+				copy(out[r*k:(r+1)*k], b)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// choleskySolve solves a·x = b in place for a symmetric positive definite a,
+// leaving x in b. a is overwritten by its factor.
+func choleskySolve(a, b []float64, k int) {
+	for j := 0; j < k; j++ {
+		d := a[j*k+j]
+		for p := 0; p < j; p++ {
+			d -= a[j*k+p] * a[j*k+p]
+		}
+		d = math.Sqrt(math.Max(d, 1e-12))
+		a[j*k+j] = d
+		for i := j + 1; i < k; i++ {
+			s := a[i*k+j]
+			for p := 0; p < j; p++ {
+				s -= a[i*k+p] * a[j*k+p]
+			}
+			a[i*k+j] = s / d
+		}
+	}
+
+	// Forward substitution with L, then back substitution with Lᵀ
+	for i := 0; i < k; i++ {
+		s := b[i]
+		for p := 0; p < i; p++ {
+			s -= a[i*k+p] * b[p]
+		}
+		b[i] = s / a[i*k+i]
+	}
+	for i := k - 1; i >= 0; i-- {
+		s := b[i]
+		for p := i + 1; p < k; p++ {
+			s -= a[p*k+i] * b[p]
+		}
+		b[i] = s / a[i*k+i]
+	}
+}
+
+// loss returns Σ c(p - xᵀy)² over all cells plus the penalty. The unobserved
+// cells are summed through the item Gram matrix, so the cost is that of the
+// observed ones.
+func loss(x, y []float64, byUser [][]entry, cfg Config) float64 {
+	k := cfg.Factors
+	gram := make([]float64, k*k)
+	var penalty float64
+	for r := 0; r < len(y)/k; r++ {
+		f := y[r*k : (r+1)*k]
+		for a := 0; a < k; a++ {
+			penalty += f[a] * f[a]
+			for b := 0; b < k; b++ {
+				gram[a*k+b] += f[a] * f[b]
+			}
+		}
+	}
+
+	var total float64
+	for u, row := range byUser {
+		xu := x[u*k : (u+1)*k]
+		for a := 0; a < k; a++ {
+			penalty += xu[a] * xu[a]
+			for b := 0; b < k; b++ {
+				total += xu[a] * gram[a*k+b] * xu[b]
+			}
+		}
+		for _, e := range row {
+			s := dot(xu, y[int(e.col)*k:int(e.col+1)*k])
+			total += e.confidence*(1-s)*(1-s) - s*s
+		}
+	}
+	return total + cfg.Regularization*penalty
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+func unflatten(ids []int64, flat []float64, k int) map[int64][]float64 {
+	out := make(map[int64][]float64, len(ids))
+	for i, id := range ids {
+		out[id] = append([]float64(nil), flat[i*k:(i+1)*k]...)
+	}
+	return out
+}
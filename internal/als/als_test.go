@@ -0,0 +1,72 @@
+package als
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrain_RecommendsItemsOfSimilarUsers(t *testing.T) {
+	// Two groups of users, each interacting with three items of its own group
+	rng := rand.New(rand.NewSource(3))
+	groups := [][]int64{{1, 2, 3, 4, 5}, {11, 12, 13, 14, 15}}
+	m := NewMatrix()
+	for userID := int64(1); userID <= 200; userID++ {
+		group := groups[userID%2]
+		for _, i := range rng.Perm(len(group))[:3] {
+			m.Add(userID, group[i], 1)
+		}
+	}
+	m.Add(1, 11, 1)
+	m.Add(1, 11, 2) // same cell
+	assert.Equal(t, 601, m.Len())
+
+	// One factor per group; more would also fit which items of its group
+	// each user skipped
+	cfg := DefaultConfig()
+	cfg.Factors = 2
+	model, err := Train(m, cfg)
+	require.NoError(t, err)
+
+	assert.Len(t, model.UserFactors, 200)
+	assert.Len(t, model.ItemFactors, 10)
+	assert.Len(t, model.UserFactors[1], 2)
+	assert.Equal(t, 601, model.Interactions)
+	assert.Less(t, model.Loss, 1.0)
+
+	// Every user prefers the items of its group over the other group's
+	for userID := int64(2); userID <= 200; userID++ {
+		x := model.UserFactors[userID]
+		own, other := groups[userID%2], groups[(userID+1)%2]
+		worstOwn := 1e9
+		for _, itemID := range own {
+			worstOwn = min(worstOwn, dot(x, model.ItemFactors[itemID]))
+		}
+		for _, itemID := range other {
+			require.Greater(t, worstOwn, dot(x, model.ItemFactors[itemID]), "user %d item %d", userID, itemID)
+		}
+	}
+
+	cfg.Workers = 1
+	again, err := Train(m, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, model.UserFactors, again.UserFactors, "training does not depend on workers")
+
+	_, err = Train(NewMatrix(), cfg)
+	assert.ErrorIs(t, err, ErrNoInteractions)
+}
+
+func TestCholeskySolve(t *testing.T) {
+	a := []float64{
+		4, 2, 0,
+		2, 5, 1,
+		0, 1, 3,
+	}
+	b := []float64{2, 8, 5}
+	want := []float64{-5.0 / 22, 16.0 / 11, 13.0 / 11}
+
+	choleskySolve(a, b, 3)
+	assert.InDeltaSlice(t, want, b, 1e-9)
+}
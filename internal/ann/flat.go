@@ -0,0 +1,62 @@
+package ann
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// Flat is an exact index that compares the query with every vector. It is
+// the baseline for measuring the recall of Index.
+type Flat struct {
+	dim  int
+	ids  []int64
+	vecs [][]float32
+}
+
+// NewFlat creates an empty exact index for vectors of dim dimensions
+func NewFlat(dim int) *Flat {
+	return &Flat{dim: dim}
+}
+
+// Len returns the number of indexed vectors
+func (f *Flat) Len() int {
+	return len(f.ids)
+}
+
+// Add inserts a vector under id
+func (f *Flat) Add(id int64, vec []float64) error {
+	q, err := normalize(vec, f.dim)
+	if err != nil {
+		return fmt.Errorf("item %d: %w", id, err)
+	}
+	f.ids = append(f.ids, id)
+	f.vecs = append(f.vecs, q)
+	return nil
+}
+
+// Search returns the k indexed vectors most similar to query, best first
+func (f *Flat) Search(query []float64, k int) ([]Result, error) {
+	q, err := normalize(query, f.dim)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the k best in a heap of distances
+	best := &maxHeap{}
+	for i, vec := range f.vecs {
+		d := 1 - dot(q, vec)
+		if best.Len() < k {
+			heap.Push(best, candidate{int32(i), d})
+		} else if k > 0 && d < (*best)[0].dist {
+			(*best)[0] = candidate{int32(i), d}
+			heap.Fix(best, 0)
+		}
+	}
+
+	results := make([]Result, best.Len())
+	for i := len(results) - 1; i >= 0; i-- {
+		c := heap.Pop(best).(candidate)
+		results[i] = Result{ID: f.ids[c.node], Score: 1 - float64(c.dist)}
+	}
+	return results, nil
+}
@@ -0,0 +1,417 @@
+// Package ann provides approximate nearest neighbour search over item
+// embeddings. Vectors are compared by cosine similarity.
+package ann
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// ErrInvalidVector is returned for vectors without a usable direction
+var ErrInvalidVector = errors.New("zero or non-finite vector")
+
+// Config holds the HNSW construction and search parameters
+type Config struct {
+	M              int // links per node above layer 0; layer 0 keeps 2*M
+	EfConstruction int // candidate list size while inserting
+	EfSearch       int // candidate list size while searching
+	Seed           int64
+}
+
+// DefaultConfig returns parameters that give good recall for catalogs of up
+// to a few million items
+func DefaultConfig() Config {
+	return Config{M: 16, EfConstruction: 200, EfSearch: 128, Seed: 1}
+}
+
+// Result is a neighbour with its cosine similarity to the query
+type Result struct {
+	ID    int64
+	Score float64
+}
+
+// Index is a Hierarchical Navigable Small World graph (Malkov & Yashunin,
+// 2016). Add is not safe for concurrent use; once built, an index may be
+// searched from many goroutines.
+type Index struct {
+	dim       int
+	cfg       Config
+	levelMult float64
+	rng       *rand.Rand
+
+	nodes    []node
+	byID     map[int64]int32
+	entry    int32
+	maxLevel int
+
+	visited sync.Pool
+}
+
+type node struct {
+	id    int64
+	vec   []float32
+	links [][]int32 // per layer
+}
+
+// New creates an empty index for vectors of dim dimensions
+func New(dim int, cfg Config) *Index {
+	defaults := DefaultConfig()
+	if cfg.M < 2 {
+		cfg.M = defaults.M
+	}
+	if cfg.EfConstruction < cfg.M {
+		cfg.EfConstruction = defaults.EfConstruction
+	}
+	if cfg.EfSearch < 1 {
+		cfg.EfSearch = defaults.EfSearch
+	}
+
+	return &Index{
+		dim:       dim,
+		cfg:       cfg,
+		levelMult: 1 / math.Log(float64(cfg.M)),
+		rng:       rand.New(rand.NewSource(cfg.Seed)),
+		byID:      make(map[int64]int32),
+		entry:     -1,
+	}
+}
+
+// Len returns the number of indexed vectors
+func (ix *Index) Len() int {
+	return len(ix.nodes)
+}
+
+// Dim returns the dimension of indexed vectors
+func (ix *Index) Dim() int {
+	return ix.dim
+}
+
+// Add inserts a vector under id. Adding an id twice is an error.
+func (ix *Index) Add(id int64, vec []float64) error {
+	if _, ok := ix.byID[id]; ok {
+		return fmt.Errorf("item %d already indexed", id)
+	}
+	q, err := normalize(vec, ix.dim)
+	if err != nil {
+		return fmt.Errorf("item %d: %w", id, err)
+	}
+
+	level := int(-math.Log(1-ix.rng.Float64()) * ix.levelMult)
+	n := int32(len(ix.nodes))
+	ix.nodes = append(ix.nodes, node{id: id, vec: q, links: make([][]int32, level+1)})
+	ix.byID[id] = n
+
+	if ix.entry < 0 {
+		ix.entry = n
+		ix.maxLevel = level
+		return nil
+	}
+
+	ep := ix.entry
+	for l := ix.maxLevel; l > level; l-- {
+		ep = ix.greedy(q, ep, l)
+	}
+
+	eps := []candidate{{ep, ix.distance(q, ep)}}
+	for l := min(level, ix.maxLevel); l >= 0; l-- {
+		found := ix.searchLayer(q, eps, ix.cfg.EfConstruction, l)
+		neighbours := ix.selectNeighbours(found, ix.maxLinks(l))
+		links := make([]int32, len(neighbours))
+		for i, c := range neighbours {
+			links[i] = c.node
+			ix.link(c.node, n, l)
+		}
+		ix.nodes[n].links[l] = links
+		eps = found
+	}
+
+	if level > ix.maxLevel {
+		ix.entry = n
+		ix.maxLevel = level
+	}
+	return nil
+}
+
+// Search returns the k indexed vectors most similar to query, best first
+func (ix *Index) Search(query []float64, k int) ([]Result, error) {
+	q, err := normalize(query, ix.dim)
+	if err != nil {
+		return nil, err
+	}
+	return ix.search(q, k, -1), nil
+}
+
+// SearchItem returns the k items most similar to an indexed item, excluding
+// the item itself. It reports false if the item is not indexed.
+func (ix *Index) SearchItem(id int64, k int) ([]Result, bool) {
+	n, ok := ix.byID[id]
+	if !ok {
+		return nil, false
+	}
+	return ix.search(ix.nodes[n].vec, k, n), true
+}
+
+func (ix *Index) search(q []float32, k int, exclude int32) []Result {
+	if ix.entry < 0 || k <= 0 {
+		return nil
+	}
+
+	ep := ix.entry
+	for l := ix.maxLevel; l > 0; l-- {
+		ep = ix.greedy(q, ep, l)
+	}
+
+	ef := ix.cfg.EfSearch
+	if ef < k+1 {
+		ef = k + 1
+	}
+	found := ix.searchLayer(q, []candidate{{ep, ix.distance(q, ep)}}, ef, 0)
+
+	results := make([]Result, 0, k)
+	for _, c := range found {
+		if c.node == exclude {
+			continue
+		}
+		results = append(results, Result{ID: ix.nodes[c.node].id, Score: 1 - float64(c.dist)})
+		if len(results) == k {
+			break
+		}
+	}
+	return results
+}
+
+// greedy walks layer l from ep towards q and returns the closest node found
+func (ix *Index) greedy(q []float32, ep int32, l int) int32 {
+	best := ix.distance(q, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, n := range ix.nodes[ep].links[l] {
+			if d := ix.distance(q, n); d < best {
+				best, ep, changed = d, n, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer returns up to ef nodes of layer l closest to q, nearest first
+func (ix *Index) searchLayer(q []float32, eps []candidate, ef, l int) []candidate {
+	visited := ix.getVisited()
+	defer ix.visited.Put(visited)
+
+	toVisit := &minHeap{}
+	found := &maxHeap{}
+	for _, c := range eps {
+		visited.visit(c.node)
+		heap.Push(toVisit, c)
+		heap.Push(found, c)
+	}
+	for found.Len() > ef {
+		heap.Pop(found)
+	}
+
+	for toVisit.Len() > 0 {
+		c := heap.Pop(toVisit).(candidate)
+		if found.Len() >= ef && c.dist > (*found)[0].dist {
+			break
+		}
+		for _, n := range ix.nodes[c.node].links[l] {
+			if !visited.visit(n) {
+				continue
+			}
+			d := ix.distance(q, n)
+			if found.Len() < ef || d < (*found)[0].dist {
+				heap.Push(toVisit, candidate{n, d})
+				heap.Push(found, candidate{n, d})
+				if found.Len() > ef {
+					heap.Pop(found)
+				}
+			}
+		}
+	}
+
+	result := make([]candidate, found.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(found).(candidate)
+	}
+	return result
+}
+
+// selectNeighbours keeps up to limit candidates, nearest first, skipping those
+// closer to an already selected neighbour than to the query. This keeps links
+// pointing in different directions, which matters for clustered data.
+func (ix *Index) selectNeighbours(candidates []candidate, limit int) []candidate {
+	if len(candidates) <= limit {
+		return candidates
+	}
+
+	selected := make([]candidate, 0, limit)
+	var pruned []candidate
+	for _, c := range candidates {
+		if len(selected) == limit {
+			break
+		}
+		keep := true
+		for _, s := range selected {
+			if dot(ix.nodes[c.node].vec, ix.nodes[s.node].vec) > 1-c.dist {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, c)
+		} else {
+			pruned = append(pruned, c)
+		}
+	}
+
+	// Fill up with the nearest pruned candidates
+	for i := 0; len(selected) < limit && i < len(pruned); i++ {
+		selected = append(selected, pruned[i])
+	}
+	return selected
+}
+
+// link adds a link from node a to node b on layer l, pruning a's links when
+// it has too many
+func (ix *Index) link(a, b int32, l int) {
+	links := append(ix.nodes[a].links[l], b)
+	if limit := ix.maxLinks(l); len(links) > limit {
+		vec := ix.nodes[a].vec
+		candidates := make([]candidate, len(links))
+		for i, n := range links {
+			candidates[i] = candidate{n, ix.distance(vec, n)}
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+
+		links = links[:0]
+		for _, c := range ix.selectNeighbours(candidates, limit) {
+			links = append(links, c.node)
+		}
+	}
+	ix.nodes[a].links[l] = links
+}
+
+func (ix *Index) maxLinks(l int) int {
+	if l == 0 {
+		return 2 * ix.cfg.M
+	}
+	return ix.cfg.M
+}
+
+func (ix *Index) distance(q []float32, n int32) float32 {
+	return 1 - dot(q, ix.nodes[n].vec)
+}
+
+func (ix *Index) getVisited() *visitedSet {
+	v, _ := ix.visited.Get().(*visitedSet)
+	if v == nil {
+		v = &visitedSet{}
+	}
+	v.reset(len(ix.nodes))
+	return v
+}
+
+// visitedSet marks nodes seen during one search. Marks are generation
+// numbers so that the set can be reused without clearing it.
+type visitedSet struct {
+	marks []uint32
+	gen   uint32
+}
+
+func (v *visitedSet) reset(n int) {
+	if len(v.marks) < n {
+		v.marks = make([]uint32, n)
+		v.gen = 0
+	}
+	v.gen++
+	if v.gen == 0 {
+		for i := range v.marks {
+			v.marks[i] = 0
+		}
+		v.gen = 1
+	}
+}
+
+// visit marks n and reports whether it was not visited before
+func (v *visitedSet) visit(n int32) bool {
+	if v.marks[n] == v.gen {
+		return false
+	}
+	v.marks[n] = v.gen
+	return true
+}
+
+type candidate struct {
+	node int32
+	dist float32
+}
+
+type minHeap []candidate
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+type maxHeap []candidate
+
+func (h maxHeap) Len() int            { return len(h) }
+func (h maxHeap) Less(i, j int) bool  { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// normalize converts vec to a unit length float32 vector
+func normalize(vec []float64, dim int) ([]float32, error) {
+	if len(vec) != dim {
+		return nil, fmt.Errorf("vector has %d dimensions, want %d", len(vec), dim)
+	}
+	var norm float64
+	for _, x := range vec {
+		norm += x * x
+	}
+	if norm == 0 || math.IsNaN(norm) || math.IsInf(norm, 0) {
+		return nil, ErrInvalidVector
+	}
+	norm = math.Sqrt(norm)
+
+	q := make([]float32, dim)
+	for i, x := range vec {
+		q[i] = float32(x / norm)
+	}
+	return q, nil
+}
+
+// dot is unrolled by four, as distance computations dominate search time
+func dot(a, b []float32) float32 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return s0 + s1 + s2 + s3
+}
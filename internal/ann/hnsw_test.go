@@ -0,0 +1,125 @@
+package ann
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clusteredVectors returns n vectors around a few random centres, which is
+// closer to trained embeddings than uniform noise
+func clusteredVectors(rng *rand.Rand, n, dim, clusters int) [][]float64 {
+	centres := make([][]float64, clusters)
+	for i := range centres {
+		centres[i] = make([]float64, dim)
+		for j := range centres[i] {
+			centres[i][j] = rng.NormFloat64()
+		}
+	}
+
+	vecs := make([][]float64, n)
+	for i := range vecs {
+		centre := centres[rng.Intn(clusters)]
+		vecs[i] = make([]float64, dim)
+		for j := range vecs[i] {
+			vecs[i][j] = centre[j] + 0.5*rng.NormFloat64()
+		}
+	}
+	return vecs
+}
+
+func buildIndexes(tb testing.TB, vecs [][]float64) (*Index, *Flat) {
+	index := New(len(vecs[0]), DefaultConfig())
+	flat := NewFlat(len(vecs[0]))
+	for i, vec := range vecs {
+		require.NoError(tb, index.Add(int64(i), vec))
+		require.NoError(tb, flat.Add(int64(i), vec))
+	}
+	return index, flat
+}
+
+// recall returns the share of exact neighbours that the index found
+func recall(tb testing.TB, index *Index, flat *Flat, queries [][]float64, k int) float64 {
+	hits := 0
+	for _, q := range queries {
+		exact, err := flat.Search(q, k)
+		require.NoError(tb, err)
+		approx, err := index.Search(q, k)
+		require.NoError(tb, err)
+
+		found := make(map[int64]bool, len(approx))
+		for _, r := range approx {
+			found[r.ID] = true
+		}
+		for _, r := range exact {
+			if found[r.ID] {
+				hits++
+			}
+		}
+	}
+	return float64(hits) / float64(len(queries)*k)
+}
+
+func TestIndex_RecallAgainstBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	index, flat := buildIndexes(t, clusteredVectors(rng, 5000, 32, 50))
+	queries := clusteredVectors(rng, 200, 32, 50)
+
+	assert.Equal(t, 5000, index.Len())
+	assert.GreaterOrEqual(t, recall(t, index, flat, queries, 10), 0.95)
+}
+
+func TestIndex_SearchItem(t *testing.T) {
+	index := New(2, DefaultConfig())
+	require.NoError(t, index.Add(1, []float64{1, 0}))
+	require.NoError(t, index.Add(2, []float64{2, 0.1}))
+	require.NoError(t, index.Add(3, []float64{0, 1}))
+	require.NoError(t, index.Add(4, []float64{-1, 0}))
+
+	results, ok := index.SearchItem(1, 2)
+	require.True(t, ok)
+	require.Len(t, results, 2)
+	assert.Equal(t, int64(2), results[0].ID)
+	assert.InDelta(t, 0.9988, results[0].Score, 1e-3)
+	assert.Equal(t, int64(3), results[1].ID)
+
+	_, ok = index.SearchItem(5, 2)
+	assert.False(t, ok)
+
+	assert.Error(t, index.Add(1, []float64{1, 1}), "duplicate id")
+	assert.ErrorIs(t, index.Add(6, []float64{0, 0}), ErrInvalidVector)
+	assert.Error(t, index.Add(7, []float64{1, 0, 0}), "wrong dimension")
+}
+
+// Run with: go test ./internal/ann -bench . -benchtime 2000x
+func BenchmarkSearch(b *testing.B) {
+	const k = 10
+	rng := rand.New(rand.NewSource(42))
+	index, flat := buildIndexes(b, clusteredVectors(rng, 20000, 64, 100))
+	queries := clusteredVectors(rng, 500, 64, 100)
+
+	for _, ef := range []int{32, 64, 128, 256} {
+		b.Run(fmt.Sprintf("hnsw/ef=%d", ef), func(b *testing.B) {
+			index.cfg.EfSearch = ef
+			for i := 0; i < b.N; i++ {
+				if _, err := index.Search(queries[i%len(queries)], k); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(recall(b, index, flat, queries, k), "recall@10")
+		})
+	}
+
+	b.Run("brute_force", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := flat.Search(queries[i%len(queries)], k); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(1, "recall@10")
+	})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdminToken rejects requests without the bearer token. An empty
+// token rejects every request, so the routes are never left open.
+func RequireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin endpoints are disabled"})
+			return
+		}
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// HandleListModels handles GET /admin/models
+func (h *Handler) HandleListModels(c *gin.Context) {
+	response, err := h.service.ListModels(c.Request.Context(), c.Query("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// HandleGetModel handles GET /admin/models/:name/versions/:version
+func (h *Handler) HandleGetModel(c *gin.Context) {
+	model, err := h.service.GetModel(c.Request.Context(), c.Param("name"), c.Param("version"))
+	if err != nil {
+		c.JSON(registryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, model)
+}
+
+// HandlePromoteModel handles POST /admin/models/:name/versions/:version/promote
+func (h *Handler) HandlePromoteModel(c *gin.Context) {
+	model, err := h.service.PromoteModel(c.Request.Context(), c.Param("name"), c.Param("version"))
+	if err != nil {
+		c.JSON(registryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, model)
+}
+
+// HandleRollbackModel handles POST /admin/models/:name/rollback
+func (h *Handler) HandleRollbackModel(c *gin.Context) {
+	model, err := h.service.RollbackModel(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		c.JSON(registryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, model)
+}
+
+// registryErrorStatus maps registry errors to HTTP status codes
+func registryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrModelNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrNoPreviousVersion), errors.Is(err, ErrModelIncomplete), errors.Is(err, ErrModelExpired):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+	"go.uber.org/zap"
+)
+
+// defaultCFRefreshInterval is used when recommendation.cf.refresh_interval is not set
+const defaultCFRefreshInterval = 5 * time.Minute
+
+// cfModel holds the item factors of one collaborative filtering model
+// version. User factors stay in Redis and are read per request.
+type cfModel struct {
+	modelID int64
+	version string
+	dim     int
+	itemIDs []int64
+	factors []float32 // dim factors per item, in itemIDs order
+}
+
+// RunCFModel loads the item factors of the served version of the configured
+// model and reloads them whenever the version changes, until ctx is cancelled
+func (s *Service) RunCFModel(ctx context.Context) {
+	cfg := s.cfg.Recommendation.CF
+	if !cfg.Enabled {
+		logger.Info("Collaborative filtering disabled")
+		return
+	}
+
+	interval := cfg.RefreshInterval
+	if interval <= 0 {
+		interval = defaultCFRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.refreshCFModel(ctx); err != nil && ctx.Err() == nil {
+			metrics.CFModelLoads.WithLabelValues("error").Inc()
+			logger.Error("Failed to load collaborative filtering model", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.reloadCF:
+		}
+	}
+}
+
+// refreshCFModel swaps in the item factors of the served model version if it
+// is not the one already loaded
+func (s *Service) refreshCFModel(ctx context.Context) error {
+	cfg := s.cfg.Recommendation.CF
+	model, err := s.servingModel(ctx, cfg.ModelName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get served model: %w", err)
+	}
+	if current := s.cf.Load(); current != nil && current.modelID == model.ID {
+		return nil
+	}
+
+	itemFactors, err := s.pgStore.GetItemEmbeddings(ctx, model.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get item factors: %w", err)
+	}
+	if len(itemFactors) == 0 {
+		return fmt.Errorf("model %s version %s has no item factors", model.ModelName, model.Version)
+	}
+
+	loaded := newCFModel(model.ID, model.Version, itemFactors)
+	s.cf.Store(loaded)
+
+	metrics.CFModelLoads.WithLabelValues("ok").Inc()
+	metrics.CFModelItems.Set(float64(len(loaded.itemIDs)))
+	logger.Info("Loaded collaborative filtering model",
+		zap.String("model", model.ModelName),
+		zap.String("version", model.Version),
+		zap.Int("items", len(loaded.itemIDs)),
+		zap.Int("factors", loaded.dim))
+	return nil
+}
+
+// newCFModel packs item factors in item order. Items whose factors have a
+// different length than the first item's are skipped.
+func newCFModel(modelID int64, version string, itemFactors map[int64][]float64) *cfModel {
+	itemIDs := make([]int64, 0, len(itemFactors))
+	for itemID := range itemFactors {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i] < itemIDs[j] })
+
+	m := &cfModel{modelID: modelID, version: version, dim: len(itemFactors[itemIDs[0]])}
+	m.factors = make([]float32, 0, len(itemIDs)*m.dim)
+	for _, itemID := range itemIDs {
+		f := itemFactors[itemID]
+		if len(f) != m.dim {
+			continue
+		}
+		m.itemIDs = append(m.itemIDs, itemID)
+		for _, x := range f {
+			m.factors = append(m.factors, float32(x))
+		}
+	}
+	return m
+}
+
+// cfItem is an item with its predicted preference
+type cfItem struct {
+	itemID int64
+	score  float64
+}
+
+// topItems scores every item against the user's factors and returns the k
+// with the highest positive scores, best first, ignoring items for which
+// skip returns true
+func (m *cfModel) topItems(user []float64, k int, skip func(int64) bool) []cfItem {
+	if len(user) != m.dim || k <= 0 {
+		return nil
+	}
+	u := make([]float32, m.dim)
+	for i, x := range user {
+		u[i] = float32(x)
+	}
+
+	top := &cfHeap{}
+	for i, itemID := range m.itemIDs {
+		var score float32
+		for d, x := range m.factors[i*m.dim : (i+1)*m.dim] {
+			score += u[d] * x
+		}
+		if score <= 0 || (top.Len() == k && float64(score) <= (*top)[0].score) || skip(itemID) {
+			continue
+		}
+		heap.Push(top, cfItem{itemID, float64(score)})
+		if top.Len() > k {
+			heap.Pop(top)
+		}
+	}
+
+	items := make([]cfItem, top.Len())
+	for i := len(items) - 1; i >= 0; i-- {
+		items[i] = heap.Pop(top).(cfItem)
+	}
+	return items
+}
+
+// expandCF adds the items with the highest predicted preference for users
+// who have factors in the loaded model, ignoring items for which skip
+// returns true
+func (s *Service) expandCF(ctx context.Context, strat strategy, userID int64, candidates map[int64]*candidateScore, skip func(int64) bool) {
+	model := s.cf.Load()
+	if model == nil {
+		return
+	}
+
+	userFactors, err := s.redisStore.GetUserFactors(ctx, model.modelID, userID)
+	if err == redis.Nil {
+		return
+	}
+	if err != nil {
+		logger.Warn("Failed to get user factors", zap.Error(err))
+		return
+	}
+
+	start := time.Now()
+	items := model.topItems(userFactors, strat.neighbourLimit, skip)
+	metrics.CFScoringLatency.Observe(time.Since(start).Seconds())
+
+	for _, item := range items {
+		if candidates[item.itemID] == nil {
+			candidates[item.itemID] = &candidateScore{}
+		}
+		candidates[item.itemID].cfScore = item.score
+	}
+}
+
+// cfHeap is a min-heap on score, holding the best items seen so far
+type cfHeap []cfItem
+
+func (h cfHeap) Len() int            { return len(h) }
+func (h cfHeap) Less(i, j int) bool  { return h[i].score < h[j].score }
+func (h cfHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *cfHeap) Push(x interface{}) { *h = append(*h, x.(cfItem)) }
+func (h *cfHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
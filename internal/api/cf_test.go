@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+func TestGenerateRecommendations_CFWithoutRecentItems(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisStore, err := store.NewRedisStore(config.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisStore.Close() })
+
+	catalog := store.NewMemoryCatalogStore(
+		models.Item{ID: 1}, models.Item{ID: 2}, models.Item{ID: 3}, models.Item{ID: 4})
+	cfg := &config.Config{Recommendation: config.RecommendationConfig{
+		Weights: config.WeightsConfig{CF: 0.5},
+		CF:      config.CFConfig{Enabled: true, ModelName: "als"},
+	}}
+	svc := NewService(cfg, redisStore, catalog)
+
+	model := &models.Model{ModelName: "als", Version: "v1", ModelType: "als"}
+	require.NoError(t, catalog.InsertModel(ctx, model))
+	_, err = catalog.SaveItemEmbeddings(ctx, model.ID, map[int64][]float64{
+		1: {1, 0},
+		2: {0.5, 0.5},
+		3: {0, 1},
+		4: {-1, 0},
+	})
+	require.NoError(t, err)
+	require.NoError(t, redisStore.SetUserFactors(ctx, model.ID, map[int64][]float64{
+		7: {2, 0.5},
+	}, 0))
+	require.NoError(t, svc.refreshCFModel(ctx))
+
+	recs, err := svc.generateRecommendations(ctx, 7, 10, Filter{}, svc.defaultStrategy())
+	require.NoError(t, err)
+	assert.Equal(t, []models.Recommendation{
+		{ItemID: 1, Score: 1, Reason: "collaborative"},
+		{ItemID: 2, Score: 0.625, Reason: "collaborative"},
+		{ItemID: 3, Score: 0.25, Reason: "collaborative"},
+	}, recs, "items with a negative preference are not candidates")
+
+	// Users without factors get no collaborative candidates
+	recs, err = svc.generateRecommendations(ctx, 8, 10, Filter{}, svc.defaultStrategy())
+	require.NoError(t, err)
+	assert.Empty(t, recs)
+}
+
+func TestRollbackModel_RefusesExpiredVersions(t *testing.T) {
+	ctx := context.Background()
+	catalog := store.NewMemoryCatalogStore()
+	svc := NewService(&config.Config{}, store.NewMemorySignalStore(), catalog)
+
+	// v1 was active, but its user factors expire right after
+	expiredAt := time.Now().Add(50 * time.Millisecond)
+	v1 := &models.Model{ModelName: "als", Version: "v1", ExpiresAt: &expiredAt}
+	require.NoError(t, catalog.InsertModel(ctx, v1))
+	require.NoError(t, catalog.ActivateModel(ctx, v1.ID))
+
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, catalog.InsertModel(ctx, &models.Model{ModelName: "als", Version: "v2", ExpiresAt: &expiresAt}))
+	_, err := svc.PromoteModel(ctx, "als", "v2")
+	require.NoError(t, err)
+	time.Sleep(time.Until(expiredAt))
+
+	_, err = svc.RollbackModel(ctx, "als")
+	assert.ErrorIs(t, err, ErrModelExpired)
+	_, err = svc.PromoteModel(ctx, "als", "v1")
+	assert.ErrorIs(t, err, ErrModelExpired)
+
+	active, err := catalog.GetActiveModel(ctx, "als")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", active.Version)
+}
+
+func TestServingModel_SkipsExpiredVersions(t *testing.T) {
+	ctx := context.Background()
+	catalog := store.NewMemoryCatalogStore()
+	svc := NewService(&config.Config{}, store.NewMemorySignalStore(), catalog)
+
+	expiresAt := time.Now().Add(time.Hour)
+	v1 := &models.Model{ModelName: "als", Version: "v1", ExpiresAt: &expiresAt}
+	require.NoError(t, catalog.InsertModel(ctx, v1))
+
+	// The latest version is complete, but its user factors have expired
+	expiredAt := time.Now().Add(-time.Hour)
+	v2 := &models.Model{ModelName: "als", Version: "v2", ExpiresAt: &expiredAt}
+	require.NoError(t, catalog.InsertModel(ctx, v2))
+
+	model, err := svc.servingModel(ctx, "als")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", model.Version)
+
+	// Activation checks expiry itself, so a version that expired after it
+	// was looked up is still refused
+	_, err = svc.activate(ctx, v2, "promote")
+	assert.ErrorIs(t, err, ErrModelExpired)
+	assert.ErrorIs(t, catalog.ActivateModel(ctx, v2.ID), store.ErrModelNotServable)
+	_, err = catalog.GetActiveModel(ctx, "als")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
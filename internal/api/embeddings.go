@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/yourusername/reco-engine/internal/ann"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+	"go.uber.org/zap"
+)
+
+// defaultEmbeddingRefreshInterval is used when recommendation.ann.refresh_interval is not set
+const defaultEmbeddingRefreshInterval = time.Minute
+
+// embeddingModel is the served version of the embedding model. Its
+// precomputed KNN lists are read under modelID; index is a nearest neighbour
+// index over its item embeddings, nil when the index is disabled.
+type embeddingModel struct {
+	modelID int64
+	version string
+	index   *ann.Index
+}
+
+// RunEmbeddingModel follows the served version of the configured embedding
+// model until ctx is cancelled, switching KNN lists and, when the index is
+// enabled, rebuilding the index whenever the version changes. Until a version
+// is loaded there are no embedding candidates; with the index loaded, the KNN
+// lists are used for items it does not cover.
+func (s *Service) RunEmbeddingModel(ctx context.Context) {
+	cfg := s.cfg.Recommendation.ANN
+	if !cfg.Enabled {
+		logger.Info("Embedding index disabled, serving precomputed KNN lists")
+	}
+
+	interval := cfg.RefreshInterval
+	if interval <= 0 {
+		interval = defaultEmbeddingRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.refreshEmbeddingModel(ctx); err != nil && ctx.Err() == nil {
+			metrics.EmbeddingIndexLoads.WithLabelValues("error").Inc()
+			logger.Error("Failed to load embedding model", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.reloadEmbeddings:
+		}
+	}
+}
+
+// refreshEmbeddingModel switches to the served model version if it is not
+// the one already loaded. The previous version keeps serving while the new
+// index is built and when building fails.
+func (s *Service) refreshEmbeddingModel(ctx context.Context) error {
+	cfg := s.cfg.Recommendation.ANN
+	model, err := s.servingModel(ctx, cfg.ModelName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get served model: %w", err)
+	}
+	if current := s.embeddings.Load(); current != nil && current.modelID == model.ID {
+		return nil
+	}
+
+	if !cfg.Enabled {
+		s.embeddings.Store(&embeddingModel{modelID: model.ID, version: model.Version})
+		logger.Info("Switched KNN lists",
+			zap.String("model", model.ModelName),
+			zap.String("version", model.Version))
+		return nil
+	}
+
+	start := time.Now()
+	embeddings, err := s.pgStore.GetItemEmbeddings(ctx, model.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get item embeddings: %w", err)
+	}
+	if len(embeddings) == 0 {
+		return fmt.Errorf("model %s version %s has no item embeddings", model.ModelName, model.Version)
+	}
+
+	index, skipped := s.buildEmbeddingIndex(embeddings)
+	s.embeddings.Store(&embeddingModel{modelID: model.ID, version: model.Version, index: index})
+
+	metrics.EmbeddingIndexLoads.WithLabelValues("ok").Inc()
+	metrics.EmbeddingIndexItems.Set(float64(index.Len()))
+	logger.Info("Loaded embedding index",
+		zap.String("model", model.ModelName),
+		zap.String("version", model.Version),
+		zap.Int("items", index.Len()),
+		zap.Int("skipped", skipped),
+		zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// buildEmbeddingIndex indexes the embeddings in item order, so that a model
+// always gives the same graph. Embeddings whose dimension differs from the
+// first item's, or that have no direction, are skipped.
+func (s *Service) buildEmbeddingIndex(embeddings map[int64][]float64) (*ann.Index, int) {
+	itemIDs := make([]int64, 0, len(embeddings))
+	for itemID := range embeddings {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i] < itemIDs[j] })
+
+	cfg := s.cfg.Recommendation.ANN
+	index := ann.New(len(embeddings[itemIDs[0]]), ann.Config{
+		M:              cfg.M,
+		EfConstruction: cfg.EfConstruction,
+		EfSearch:       cfg.EfSearch,
+		Seed:           1,
+	})
+
+	skipped := 0
+	for _, itemID := range itemIDs {
+		if err := index.Add(itemID, embeddings[itemID]); err != nil {
+			logger.Debug("Skipping item embedding", zap.Error(err))
+			skipped++
+		}
+	}
+	return index, skipped
+}
+
+// embeddingNeighbours returns the items most similar to itemID in the loaded
+// index. It reports false when no index is loaded or the item is not in it.
+func (s *Service) embeddingNeighbours(itemID int64, count int) ([]ann.Result, bool) {
+	current := s.embeddings.Load()
+	if current == nil || current.index == nil {
+		return nil, false
+	}
+
+	start := time.Now()
+	neighbours, ok := current.index.SearchItem(itemID, count)
+	metrics.EmbeddingSearchLatency.Observe(time.Since(start).Seconds())
+	return neighbours, ok
+}
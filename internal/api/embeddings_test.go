@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+func TestRefreshEmbeddingModel_FollowsServedVersion(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisStore, err := store.NewRedisStore(config.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisStore.Close() })
+
+	catalog := store.NewMemoryCatalogStore(
+		models.Item{ID: 1}, models.Item{ID: 2}, models.Item{ID: 3}, models.Item{ID: 4}, models.Item{ID: 9})
+	cfg := &config.Config{Recommendation: config.RecommendationConfig{
+		Weights: config.WeightsConfig{Embedding: 1},
+		ANN:     config.ANNConfig{Enabled: true, ModelName: "item2vec"},
+	}}
+	svc := NewService(cfg, redisStore, catalog)
+
+	// Without a model there are no embedding candidates
+	require.NoError(t, svc.refreshEmbeddingModel(ctx))
+	assert.Nil(t, svc.embeddings.Load())
+
+	v1 := &models.Model{ModelName: "item2vec", Version: "v1"}
+	require.NoError(t, catalog.InsertModel(ctx, v1))
+	_, err = catalog.SaveItemEmbeddings(ctx, v1.ID, map[int64][]float64{
+		1: {1, 0},
+		2: {1, 0.1},
+		3: {0, 1},
+		4: {-1, 0},
+	})
+	require.NoError(t, err)
+	require.NoError(t, redisStore.SetItemKNN(ctx, v1.ID, 9, []int64{1}))
+	require.NoError(t, svc.refreshEmbeddingModel(ctx))
+
+	recs, err := svc.GetSimilarItems(ctx, 1, "", 2)
+	require.NoError(t, err)
+	require.Len(t, recs, 1, "orthogonal and opposite items are not similar")
+	assert.Equal(t, int64(2), recs[0].ItemID)
+	assert.Equal(t, "embedding", recs[0].Reason)
+	assert.InDelta(t, 0.995, recs[0].Score, 1e-3)
+
+	// Items outside the index fall back to the model's precomputed lists
+	recs, err = svc.GetSimilarItems(ctx, 9, "", 2)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, int64(1), recs[0].ItemID)
+
+	// Without a promotion the latest version is served
+	v2 := &models.Model{ModelName: "item2vec", Version: "v2"}
+	require.NoError(t, catalog.InsertModel(ctx, v2))
+	_, err = catalog.SaveItemEmbeddings(ctx, v2.ID, map[int64][]float64{
+		1: {1, 0},
+		2: {0, 1},
+		3: {1, 0.1},
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.refreshEmbeddingModel(ctx))
+
+	recs, err = svc.GetSimilarItems(ctx, 1, "", 2)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, int64(3), recs[0].ItemID)
+	assert.Equal(t, "v2", svc.embeddings.Load().version)
+	recs, err = svc.GetSimilarItems(ctx, 9, "", 2)
+	require.NoError(t, err)
+	assert.Empty(t, recs, "lists of other versions are not served")
+
+	// A promoted version is served until another one is promoted
+	_, err = svc.RollbackModel(ctx, "item2vec")
+	assert.ErrorIs(t, err, ErrNoPreviousVersion)
+	_, err = svc.PromoteModel(ctx, "item2vec", "v0")
+	assert.ErrorIs(t, err, ErrModelNotFound)
+
+	promoted, err := svc.PromoteModel(ctx, "item2vec", "v1")
+	require.NoError(t, err)
+	assert.True(t, promoted.Active)
+	require.NoError(t, catalog.InsertModel(ctx, &models.Model{ModelName: "item2vec", Version: "v3"}))
+	require.NoError(t, svc.refreshEmbeddingModel(ctx))
+	assert.Equal(t, "v1", svc.embeddings.Load().version)
+
+	recs, err = svc.GetSimilarItems(ctx, 1, "", 2)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, int64(2), recs[0].ItemID)
+
+	// Rolling back returns to the previously promoted version
+	_, err = svc.PromoteModel(ctx, "item2vec", "v2")
+	require.NoError(t, err)
+	require.NoError(t, svc.refreshEmbeddingModel(ctx))
+	assert.Equal(t, "v2", svc.embeddings.Load().version)
+
+	rolledBack, err := svc.RollbackModel(ctx, "item2vec")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", rolledBack.Version)
+	require.NoError(t, svc.refreshEmbeddingModel(ctx))
+	assert.Equal(t, "v1", svc.embeddings.Load().version)
+
+	list, err := svc.ListModels(ctx, "item2vec")
+	require.NoError(t, err)
+	require.Len(t, list.Models, 3)
+	assert.Equal(t, []string{"v3", "v2", "v1"},
+		[]string{list.Models[0].Version, list.Models[1].Version, list.Models[2].Version})
+	assert.Equal(t, map[string]string{"item2vec": "v1"}, list.Serving)
+}
+
+func TestRefreshEmbeddingModel_SkipsPendingVersions(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisStore, err := store.NewRedisStore(config.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisStore.Close() })
+
+	catalog := store.NewMemoryCatalogStore(models.Item{ID: 1}, models.Item{ID: 2})
+	cfg := &config.Config{Recommendation: config.RecommendationConfig{
+		ANN: config.ANNConfig{Enabled: true, ModelName: "item2vec"},
+	}}
+	svc := NewService(cfg, redisStore, catalog)
+	vectors := map[int64][]float64{1: {1, 0}, 2: {0, 1}}
+
+	v1 := &models.Model{ModelName: "item2vec", Version: "v1"}
+	require.NoError(t, catalog.InsertModel(ctx, v1))
+	assert.Equal(t, models.ModelStatusComplete, v1.Status)
+	_, err = catalog.SaveItemEmbeddings(ctx, v1.ID, vectors)
+	require.NoError(t, err)
+
+	// A version whose trainer is still writing is neither served nor promoted
+	v2 := &models.Model{ModelName: "item2vec", Version: "v2", Status: models.ModelStatusPending}
+	require.NoError(t, catalog.InsertModel(ctx, v2))
+	_, err = catalog.SaveItemEmbeddings(ctx, v2.ID, vectors)
+	require.NoError(t, err)
+	require.NoError(t, svc.refreshEmbeddingModel(ctx))
+	assert.Equal(t, "v1", svc.embeddings.Load().version)
+	_, err = svc.PromoteModel(ctx, "item2vec", "v2")
+	assert.ErrorIs(t, err, ErrModelIncomplete)
+
+	require.NoError(t, catalog.CompleteModel(ctx, v2.ID))
+	require.NoError(t, svc.refreshEmbeddingModel(ctx))
+	assert.Equal(t, "v2", svc.embeddings.Load().version)
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+	"go.uber.org/zap"
+)
+
+// Candidate sources that a strategy can enable
+const (
+	sourceCoview      = "coview"
+	sourceKNN         = "knn"
+	sourcePopular     = "popular"
+	sourceTransitions = "transitions"
+	sourceCF          = "cf"
+)
+
+// allSources lists every candidate source, all of which the default strategy
+// enables
+var allSources = []string{sourceCoview, sourceKNN, sourcePopular, sourceTransitions, sourceCF}
+
+const (
+	defaultSeedCount      = 5
+	defaultNeighbourLimit = 20
+)
+
+// strategy holds the parameters used to generate recommendations for one
+// request: the global configuration with experiment overrides applied
+type strategy struct {
+	weights        config.WeightsConfig
+	sources        map[string]bool
+	seedCount      int
+	neighbourLimit int
+	assignments    []models.ExperimentAssignment
+}
+
+func (st strategy) uses(source string) bool {
+	return st.sources[source]
+}
+
+// defaultStrategy returns the strategy from the global configuration
+func (s *Service) defaultStrategy() strategy {
+	st := strategy{
+		weights:        s.cfg.Recommendation.Weights,
+		sources:        make(map[string]bool, len(allSources)),
+		seedCount:      defaultSeedCount,
+		neighbourLimit: defaultNeighbourLimit,
+	}
+	for _, source := range allSources {
+		st.sources[source] = true
+	}
+	return st
+}
+
+// ValidateExperiments checks that the variants of every configured
+// experiment, enabled or not, only name known candidate sources, so that a
+// typo fails at startup instead of silently dropping a source
+func ValidateExperiments(experiments []config.ExperimentConfig) error {
+	known := make(map[string]bool, len(allSources))
+	for _, source := range allSources {
+		known[source] = true
+	}
+
+	for _, exp := range experiments {
+		for _, variant := range exp.Variants {
+			for _, source := range variant.Sources {
+				if !known[source] {
+					return fmt.Errorf("experiment %q variant %q: unknown source %q (known: %s)",
+						exp.Name, variant.Name, source, strings.Join(allSources, ", "))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// strategyFor assigns the user to a variant of every enabled experiment and
+// applies the variant overrides in experiment order
+func (s *Service) strategyFor(userID int64) strategy {
+	st := s.defaultStrategy()
+
+	for _, exp := range s.cfg.Experiments {
+		if !exp.Enabled {
+			continue
+		}
+		variant, ok := assignVariant(exp, userID)
+		if !ok {
+			continue
+		}
+
+		if variant.Weights != nil {
+			st.weights = *variant.Weights
+		}
+		if len(variant.Sources) > 0 {
+			st.sources = make(map[string]bool, len(variant.Sources))
+			for _, source := range variant.Sources {
+				st.sources[source] = true
+			}
+		}
+		if variant.SeedCount > 0 {
+			st.seedCount = variant.SeedCount
+		}
+		if variant.NeighbourLimit > 0 {
+			st.neighbourLimit = variant.NeighbourLimit
+		}
+
+		st.assignments = append(st.assignments, models.ExperimentAssignment{
+			Experiment: exp.Name,
+			Variant:    variant.Name,
+		})
+	}
+
+	return st
+}
+
+// assignVariant deterministically picks a variant by hashing the experiment
+// name with the user ID, so a user keeps their variant across requests and
+// assignments are independent between experiments
+func assignVariant(exp config.ExperimentConfig, userID int64) (config.VariantConfig, bool) {
+	total := 0
+	for _, v := range exp.Variants {
+		if v.Allocation > 0 {
+			total += v.Allocation
+		}
+	}
+	if total == 0 {
+		return config.VariantConfig{}, false
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(exp.Name))
+	h.Write([]byte{':'})
+	h.Write([]byte(strconv.FormatInt(userID, 10)))
+	bucket := int(h.Sum64() % uint64(total))
+
+	for _, v := range exp.Variants {
+		if v.Allocation <= 0 {
+			continue
+		}
+		if bucket < v.Allocation {
+			return v, true
+		}
+		bucket -= v.Allocation
+	}
+	return config.VariantConfig{}, false
+}
+
+// recordExposures tags metrics with the served variants and emits one
+// exposure log line per experiment so that clicks can be joined back
+func recordExposures(response *models.RecommendationResponse, latency time.Duration) {
+	if len(response.Experiments) == 0 {
+		return
+	}
+
+	itemIDs := make([]int64, len(response.Recommendations))
+	for i, rec := range response.Recommendations {
+		itemIDs[i] = rec.ItemID
+	}
+
+	for _, a := range response.Experiments {
+		metrics.ExperimentExposures.WithLabelValues(a.Experiment, a.Variant).Inc()
+		metrics.ExperimentLatency.WithLabelValues(a.Experiment, a.Variant).Observe(latency.Seconds())
+
+		logger.Info("Experiment exposure",
+			zap.String("log_type", "experiment_exposure"),
+			zap.String("experiment", a.Experiment),
+			zap.String("variant", a.Variant),
+			zap.Int64("user_id", response.UserID),
+			zap.Int64s("item_ids", itemIDs),
+			zap.Time("exposed_at", time.Now()))
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+func TestAssignVariant_DeterministicAndBalanced(t *testing.T) {
+	exp := config.ExperimentConfig{
+		Name:    "weights-test",
+		Enabled: true,
+		Variants: []config.VariantConfig{
+			{Name: "control", Allocation: 50},
+			{Name: "treatment", Allocation: 50},
+		},
+	}
+
+	counts := map[string]int{}
+	for userID := int64(1); userID <= 10000; userID++ {
+		v, ok := assignVariant(exp, userID)
+		require.True(t, ok)
+
+		again, _ := assignVariant(exp, userID)
+		assert.Equal(t, v.Name, again.Name)
+		counts[v.Name]++
+	}
+
+	assert.InDelta(t, 5000, counts["control"], 300)
+	assert.InDelta(t, 5000, counts["treatment"], 300)
+}
+
+func TestStrategyFor_AppliesVariantOverrides(t *testing.T) {
+	treatment := config.WeightsConfig{Coview: 1}
+	cfg := &config.Config{
+		Recommendation: config.RecommendationConfig{
+			Weights: config.WeightsConfig{Coview: 0.4, Embedding: 0.3},
+		},
+		Experiments: []config.ExperimentConfig{
+			{
+				Name:    "all-treatment",
+				Enabled: true,
+				Variants: []config.VariantConfig{
+					{Name: "treatment", Allocation: 1, Weights: &treatment, Sources: []string{"coview"}, SeedCount: 3},
+				},
+			},
+			{
+				Name:     "disabled",
+				Variants: []config.VariantConfig{{Name: "control", Allocation: 1}},
+			},
+		},
+	}
+	svc := &Service{cfg: cfg}
+
+	st := svc.strategyFor(42)
+	assert.Equal(t, treatment, st.weights)
+	assert.True(t, st.uses(sourceCoview))
+	assert.False(t, st.uses(sourceKNN))
+	assert.Equal(t, 3, st.seedCount)
+	assert.Equal(t, defaultNeighbourLimit, st.neighbourLimit)
+	require.Len(t, st.assignments, 1)
+	assert.Equal(t, "all-treatment", st.assignments[0].Experiment)
+	assert.Equal(t, "treatment", st.assignments[0].Variant)
+}
+
+func TestValidateExperiments_RejectsUnknownSources(t *testing.T) {
+	experiments := []config.ExperimentConfig{{
+		Name: "sources",
+		Variants: []config.VariantConfig{
+			{Name: "control", Allocation: 1},
+			{Name: "treatment", Allocation: 1, Sources: []string{"coview", "knn", "popular", "transitions", "cf"}},
+		},
+	}}
+	assert.NoError(t, ValidateExperiments(experiments))
+
+	// Disabled experiments are checked too, so enabling one cannot fail later
+	experiments = append(experiments, config.ExperimentConfig{
+		Name:     "typo",
+		Variants: []config.VariantConfig{{Name: "treatment", Allocation: 1, Sources: []string{"coview", "popularity"}}},
+	})
+	err := ValidateExperiments(experiments)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `experiment "typo" variant "treatment": unknown source "popularity"`)
+}
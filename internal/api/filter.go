@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+// Filter holds the business rules applied to candidates after generation
+type Filter struct {
+	ExcludeOutOfStock    bool
+	ExcludePurchasedDays int
+	ExcludeIDs           []int64
+	Category             string
+	MinPrice             int64
+	MaxPrice             int64
+}
+
+// DefaultFilter returns the filter configured under recommendation.filters
+func DefaultFilter(cfg config.FilterConfig) Filter {
+	return Filter{
+		ExcludeOutOfStock:    cfg.ExcludeOutOfStock,
+		ExcludePurchasedDays: cfg.ExcludePurchasedDays,
+	}
+}
+
+// needsItems reports whether the filter checks catalog attributes
+func (f Filter) needsItems() bool {
+	return f.ExcludeOutOfStock || f.Category != "" || f.MinPrice > 0 || f.MaxPrice > 0
+}
+
+// applyFilters removes candidates that violate the filter. Item attributes
+// come from the item cache with misses loaded in a single batch query;
+// candidates missing from the catalog are dropped when an attribute rule is
+// active.
+func (s *Service) applyFilters(ctx context.Context, userID int64, candidates map[int64]*candidateScore, filter Filter) error {
+	for _, itemID := range filter.ExcludeIDs {
+		delete(candidates, itemID)
+	}
+
+	if userID > 0 && filter.ExcludePurchasedDays > 0 && len(candidates) > 0 {
+		since := s.now().AddDate(0, 0, -filter.ExcludePurchasedDays)
+		purchased, err := s.redisStore.GetPurchasedItemsSince(ctx, userID, since)
+		if err != nil {
+			return err
+		}
+		for _, itemStr := range purchased {
+			if itemID, err := strconv.ParseInt(itemStr, 10, 64); err == nil {
+				delete(candidates, itemID)
+			}
+		}
+	}
+
+	if !filter.needsItems() || len(candidates) == 0 {
+		return nil
+	}
+
+	itemIDs := make([]int64, 0, len(candidates))
+	for itemID := range candidates {
+		itemIDs = append(itemIDs, itemID)
+	}
+
+	items, err := s.loadItems(ctx, itemIDs)
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[int64]bool, len(items))
+	for _, item := range items {
+		switch {
+		case filter.ExcludeOutOfStock && item.Stock <= 0:
+		case filter.Category != "" && item.Category != filter.Category:
+		case filter.MinPrice > 0 && item.Price < filter.MinPrice:
+		case filter.MaxPrice > 0 && item.Price > filter.MaxPrice:
+		default:
+			keep[item.ID] = true
+		}
+	}
+
+	for itemID := range candidates {
+		if !keep[itemID] {
+			delete(candidates, itemID)
+		}
+	}
+	return nil
+}
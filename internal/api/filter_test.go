@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+func TestApplyFilters(t *testing.T) {
+	ctx := context.Background()
+	signals := store.NewMemorySignalStore()
+	catalog := store.NewMemoryCatalogStore(
+		models.Item{ID: 1, Category: "electronics", Price: 100, Stock: 5},
+		models.Item{ID: 2, Category: "electronics", Price: 500, Stock: 0},
+		models.Item{ID: 3, Category: "books", Price: 20, Stock: 3},
+		models.Item{ID: 4, Category: "electronics", Price: 900, Stock: 1},
+		models.Item{ID: 5, Category: "books", Price: 50, Stock: 8},
+	)
+	svc := NewService(&config.Config{}, signals, catalog)
+
+	// User 7 bought item 5 yesterday and item 3 a month ago
+	now := time.Now()
+	batch := store.NewSignalBatch(50, 90*24*time.Hour)
+	batch.AddPurchase(7, 5, now.Add(-24*time.Hour))
+	batch.AddPurchase(7, 3, now.Add(-30*24*time.Hour))
+	require.NoError(t, signals.ApplySignalBatch(ctx, batch))
+
+	tests := []struct {
+		name   string
+		userID int64
+		filter Filter
+		want   []int64
+	}{
+		{"no filter", 7, Filter{}, []int64{1, 2, 3, 4, 5, 6}},
+		{"out of stock", 7, Filter{ExcludeOutOfStock: true}, []int64{1, 3, 4, 5}},
+		{"purchased within days", 7, Filter{ExcludePurchasedDays: 7}, []int64{1, 2, 3, 4, 6}},
+		{"purchased by another user", 8, Filter{ExcludePurchasedDays: 7}, []int64{1, 2, 3, 4, 5, 6}},
+		{"exclude ids", 7, Filter{ExcludeIDs: []int64{2, 4}}, []int64{1, 3, 5, 6}},
+		{"price range", 7, Filter{MinPrice: 50, MaxPrice: 500}, []int64{1, 2, 5}},
+		{"category", 7, Filter{Category: "books"}, []int64{3, 5}},
+		{"combined", 7, Filter{ExcludeOutOfStock: true, ExcludePurchasedDays: 7, Category: "electronics", MaxPrice: 500}, []int64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Item 6 is not in the catalog, so it is dropped by attribute rules
+			candidates := make(map[int64]*candidateScore)
+			for itemID := int64(1); itemID <= 6; itemID++ {
+				candidates[itemID] = &candidateScore{}
+			}
+
+			require.NoError(t, svc.applyFilters(ctx, tt.userID, candidates, tt.filter))
+			got := make([]int64, 0, len(candidates))
+			for itemID := range candidates {
+				got = append(got, itemID)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -38,12 +40,30 @@ func (h *Handler) HandleGetRecommendations(c *gin.Context) {
 		return
 	}
 
-	response, err := h.service.GetRecommendations(c.Request.Context(), userID, count)
+	filter, err := h.parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.GetRecommendations(c.Request.Context(), userID, count, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if includeItems(c) {
+		hydrated, err := h.service.HydrateItems(c.Request.Context(), response.Recommendations)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Copy so that the response being cached is not modified
+		hydratedResponse := *response
+		hydratedResponse.Recommendations = hydrated
+		response = &hydratedResponse
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -64,13 +84,173 @@ func (h *Handler) HandleGetPopular(c *gin.Context) {
 		return
 	}
 
+	if includeItems(c) {
+		recommendations, err = h.service.HydrateItems(c.Request.Context(), recommendations)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"category":        category,
 		"recommendations": recommendations,
 	})
 }
 
+// HandleGetSimilarItems handles GET /items/:id/similar
+func (h *Handler) HandleGetSimilarItems(c *gin.Context) {
+	itemID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		return
+	}
+
+	category := c.Query("category")
+
+	countStr := c.DefaultQuery("count", "10")
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid count"})
+		return
+	}
+
+	recommendations, err := h.service.GetSimilarItems(c.Request.Context(), itemID, category, count)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if includeItems(c) {
+		recommendations, err = h.service.HydrateItems(c.Request.Context(), recommendations)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"item_id":         itemID,
+		"category":        category,
+		"recommendations": recommendations,
+	})
+}
+
+// HandleGetBoughtTogether handles GET /items/:id/bought-together
+func (h *Handler) HandleGetBoughtTogether(c *gin.Context) {
+	itemID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		return
+	}
+
+	countStr := c.DefaultQuery("count", "10")
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid count"})
+		return
+	}
+
+	recommendations, err := h.service.GetBoughtTogether(c.Request.Context(), itemID, count)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if includeItems(c) {
+		recommendations, err = h.service.HydrateItems(c.Request.Context(), recommendations)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"item_id":         itemID,
+		"recommendations": recommendations,
+	})
+}
+
 // HandleHealth handles GET /health
 func (h *Handler) HandleHealth(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 }
+
+// filterParams are the query parameters that override the default filter
+var filterParams = []string{"exclude", "exclude_out_of_stock", "exclude_purchased_days", "category", "min_price", "max_price"}
+
+// parseFilter builds a filter from query parameters on top of the configured
+// defaults. It returns nil when the request sets none of them.
+func (h *Handler) parseFilter(c *gin.Context) (*Filter, error) {
+	custom := false
+	for _, param := range filterParams {
+		if _, ok := c.GetQuery(param); ok {
+			custom = true
+			break
+		}
+	}
+	if !custom {
+		return nil, nil
+	}
+
+	filter := DefaultFilter(h.service.cfg.Recommendation.Filters)
+	filter.Category = c.Query("category")
+
+	if v, ok := c.GetQuery("exclude"); ok && v != "" {
+		for _, idStr := range strings.Split(v, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(idStr), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclude")
+			}
+			filter.ExcludeIDs = append(filter.ExcludeIDs, id)
+		}
+	}
+
+	if v, ok := c.GetQuery("exclude_out_of_stock"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_out_of_stock")
+		}
+		filter.ExcludeOutOfStock = b
+	}
+
+	if v, ok := c.GetQuery("exclude_purchased_days"); ok {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid exclude_purchased_days")
+		}
+		filter.ExcludePurchasedDays = days
+	}
+
+	if v, ok := c.GetQuery("min_price"); ok {
+		price, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("invalid min_price")
+		}
+		filter.MinPrice = price
+	}
+
+	if v, ok := c.GetQuery("max_price"); ok {
+		price, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("invalid max_price")
+		}
+		filter.MaxPrice = price
+	}
+
+	if filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		return nil, fmt.Errorf("min_price exceeds max_price")
+	}
+
+	return &filter, nil
+}
+
+// includeItems reports whether the request asks for item details (include=item)
+func includeItems(c *gin.Context) bool {
+	for _, part := range strings.Split(c.Query("include"), ",") {
+		if strings.TrimSpace(part) == "item" {
+			return true
+		}
+	}
+	return false
+}
@@ -1,12 +1,17 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
 	"github.com/yourusername/reco-engine/internal/util/config"
 )
 
@@ -29,6 +34,86 @@ func TestHandleGetRecommendations_MissingUserID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestHandleGetRecommendations_InvalidFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	svc := &Service{cfg: cfg}
+	handler := NewHandler(svc)
+
+	router := gin.New()
+	router.GET("/recommendations", handler.HandleGetRecommendations)
+
+	for _, query := range []string{"exclude=1,x", "min_price=500&max_price=100", "exclude_out_of_stock=maybe"} {
+		req, _ := http.NewRequest("GET", "/recommendations?user_id=1&"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestHandleGetSimilarItems_InvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	svc := &Service{cfg: cfg}
+	handler := NewHandler(svc)
+
+	req, _ := http.NewRequest("GET", "/items/abc/similar", nil)
+	w := httptest.NewRecorder()
+
+	router := gin.New()
+	router.GET("/items/:id/similar", handler.HandleGetSimilarItems)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleGetPopular_ByCategory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	signals := store.NewMemorySignalStore()
+	batch := store.NewSignalBatch(50, 0)
+	batch.AddPopularity(1, 10)
+	batch.AddPopularity(2, 5)
+	batch.AddPopularity(3, 1)
+	batch.AddCategoryPopularity("books", 2, 5)
+	batch.AddCategoryPopularity("books", 3, 1)
+	require.NoError(t, signals.ApplySignalBatch(context.Background(), batch))
+	handler := NewHandler(NewService(&config.Config{}, signals, store.NewMemoryCatalogStore()))
+
+	router := gin.New()
+	router.GET("/popular", handler.HandleGetPopular)
+
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{"", []int64{1, 2, 3}},
+		{"?category=books", []int64{2, 3}},
+		{"?category=books&count=1", []int64{2}},
+		{"?category=toys", nil},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/popular"+tt.query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, tt.query)
+
+		var response struct {
+			Recommendations []models.Recommendation `json:"recommendations"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var got []int64
+		for _, rec := range response.Recommendations {
+			got = append(got, rec.ItemID)
+			assert.Equal(t, "popular", rec.Reason)
+		}
+		assert.Equal(t, tt.want, got, tt.query)
+	}
+}
+
 func TestHandleHealth(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -45,3 +130,53 @@ func TestHandleHealth(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestAdminRoutes_RequireToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	handler := NewHandler(NewService(cfg, nil, store.NewMemoryCatalogStore()))
+
+	router := gin.New()
+	admin := router.Group("/admin", RequireAdminToken("secret"))
+	admin.GET("/models/:name/versions/:version", handler.HandleGetModel)
+	admin.POST("/models/:name/rollback", handler.HandleRollbackModel)
+
+	req, _ := http.NewRequest("GET", "/admin/models/als/versions/v1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req, _ = http.NewRequest("GET", "/admin/models/als/versions/v1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("POST", "/admin/models/als/rollback", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestAdminRoutes_EmptyTokenRejectsAll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	handler := NewHandler(NewService(cfg, nil, store.NewMemoryCatalogStore()))
+
+	router := gin.New()
+	admin := router.Group("/admin", RequireAdminToken(""))
+	admin.GET("/models/:name/versions/:version", handler.HandleGetModel)
+
+	for _, auth := range []string{"", "Bearer ", "Bearer anything"} {
+		req, _ := http.NewRequest("GET", "/admin/models/als/versions/v1", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code, auth)
+	}
+}
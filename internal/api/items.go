@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/reco-engine/internal/models"
+)
+
+// HydrateItems returns a copy of recommendations with item details attached.
+// Recommendations whose item is not in the catalog are returned without details.
+func (s *Service) HydrateItems(ctx context.Context, recommendations []models.Recommendation) ([]models.Recommendation, error) {
+	itemIDs := make([]int64, len(recommendations))
+	for i, rec := range recommendations {
+		itemIDs[i] = rec.ItemID
+	}
+
+	items, err := s.loadItems(ctx, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load items: %w", err)
+	}
+
+	hydrated := make([]models.Recommendation, len(recommendations))
+	for i, rec := range recommendations {
+		rec.Item = items[rec.ItemID]
+		hydrated[i] = rec
+	}
+	return hydrated, nil
+}
+
+// loadItems returns catalog items by ID, serving from the in-process item
+// cache and loading all misses in a single query. Items missing from the
+// catalog are absent from the result and cached as such.
+func (s *Service) loadItems(ctx context.Context, itemIDs []int64) (map[int64]*models.Item, error) {
+	items := make(map[int64]*models.Item, len(itemIDs))
+
+	var missing []int64
+	for _, itemID := range itemIDs {
+		item, ok := s.itemCache.Get(itemID)
+		if !ok {
+			missing = append(missing, itemID)
+			continue
+		}
+		if item != nil {
+			items[itemID] = item
+		}
+	}
+
+	if len(missing) == 0 {
+		return items, nil
+	}
+
+	loaded, err := s.pgStore.GetItems(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range loaded {
+		item := &loaded[i]
+		items[item.ID] = item
+		s.itemCache.Set(item.ID, item)
+	}
+	for _, itemID := range missing {
+		if items[itemID] == nil {
+			s.itemCache.Set(itemID, nil)
+		}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+// countingCatalog records the item IDs of every GetItems query
+type countingCatalog struct {
+	store.CatalogStore
+	queries [][]int64
+}
+
+func (c *countingCatalog) GetItems(ctx context.Context, itemIDs []int64) ([]models.Item, error) {
+	c.queries = append(c.queries, append([]int64(nil), itemIDs...))
+	return c.CatalogStore.GetItems(ctx, itemIDs)
+}
+
+func TestHydrateItems_CachesItems(t *testing.T) {
+	ctx := context.Background()
+	catalog := &countingCatalog{CatalogStore: store.NewMemoryCatalogStore(
+		models.Item{ID: 1, Title: "Laptop"},
+		models.Item{ID: 2, Title: "Mouse"},
+	)}
+	svc := NewService(&config.Config{}, store.NewMemorySignalStore(), catalog)
+	recs := []models.Recommendation{{ItemID: 1}, {ItemID: 2}, {ItemID: 3}}
+
+	// Misses are loaded in one batch; item 3 is not in the catalog
+	hydrated, err := svc.HydrateItems(ctx, recs)
+	require.NoError(t, err)
+	assert.Equal(t, [][]int64{{1, 2, 3}}, catalog.queries)
+	require.Len(t, hydrated, 3)
+	assert.Equal(t, "Laptop", hydrated[0].Item.Title)
+	assert.Equal(t, "Mouse", hydrated[1].Item.Title)
+	assert.Nil(t, hydrated[2].Item)
+	assert.Nil(t, recs[0].Item, "the input is not modified")
+
+	// Cached items and cached misses are served without a query
+	hydrated, err = svc.HydrateItems(ctx, recs)
+	require.NoError(t, err)
+	assert.Len(t, catalog.queries, 1)
+	assert.Equal(t, "Laptop", hydrated[0].Item.Title)
+	assert.Nil(t, hydrated[2].Item)
+
+	// Only new items are loaded
+	_, err = svc.HydrateItems(ctx, []models.Recommendation{{ItemID: 2}, {ItemID: 4}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]int64{{1, 2, 3}, {4}}, catalog.queries)
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrModelNotFound is returned for a model version that is not registered
+	ErrModelNotFound = errors.New("model not found")
+	// ErrNoPreviousVersion is returned when rolling back a model that has no
+	// previously active version
+	ErrNoPreviousVersion = errors.New("no previously active version")
+	// ErrModelIncomplete is returned when promoting a model version whose
+	// data is still being written
+	ErrModelIncomplete = errors.New("model version is not complete")
+	// ErrModelExpired is returned when activating a model version whose Redis
+	// data has expired
+	ErrModelExpired = errors.New("model version data has expired")
+)
+
+// servingModel returns the active version of a model, or its latest complete
+// version whose data has not expired when none has been promoted. It returns
+// pgx.ErrNoRows if there is no such version.
+func (s *Service) servingModel(ctx context.Context, modelName string) (*models.Model, error) {
+	model, err := s.pgStore.GetActiveModel(ctx, modelName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.pgStore.GetLatestModel(ctx, modelName)
+	}
+	return model, err
+}
+
+// ListModels returns the registered versions of a model, or of all models
+// when modelName is empty, along with the versions this instance serves
+func (s *Service) ListModels(ctx context.Context, modelName string) (*models.ModelListResponse, error) {
+	list, err := s.pgStore.ListModels(ctx, modelName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	if list == nil {
+		list = []models.Model{}
+	}
+	return &models.ModelListResponse{Models: list, Serving: s.servedVersions()}, nil
+}
+
+// servedVersions returns the version this instance serves per model name
+func (s *Service) servedVersions() map[string]string {
+	serving := make(map[string]string)
+	if current := s.embeddings.Load(); current != nil {
+		serving[s.cfg.Recommendation.ANN.ModelName] = current.version
+	}
+	if current := s.cf.Load(); current != nil {
+		serving[s.cfg.Recommendation.CF.ModelName] = current.version
+	}
+	return serving
+}
+
+// LoadModels loads the served versions of the embedding and collaborative
+// filtering models once and returns them by model name. Offline evaluation
+// uses it in place of the refresh loops.
+func (s *Service) LoadModels(ctx context.Context) (map[string]*models.Model, error) {
+	if err := s.refreshEmbeddingModel(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load embedding model: %w", err)
+	}
+	if s.cfg.Recommendation.CF.Enabled {
+		if err := s.refreshCFModel(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load collaborative filtering model: %w", err)
+		}
+	}
+
+	loaded := make(map[string]*models.Model)
+	for name, version := range s.servedVersions() {
+		model, err := s.GetModel(ctx, name, version)
+		if err != nil {
+			return nil, err
+		}
+		loaded[name] = model
+	}
+	return loaded, nil
+}
+
+// GetModel returns one version of a model with its metrics and config
+func (s *Service) GetModel(ctx context.Context, modelName, version string) (*models.Model, error) {
+	model, err := s.pgStore.GetModel(ctx, modelName, version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrModelNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get model: %w", err)
+	}
+	return model, nil
+}
+
+// PromoteModel makes a version the active one for its model name. Every API
+// instance switches to it at its next refresh; this one reloads at once.
+func (s *Service) PromoteModel(ctx context.Context, modelName, version string) (*models.Model, error) {
+	model, err := s.GetModel(ctx, modelName, version)
+	if err != nil {
+		return nil, err
+	}
+	if model.Status != models.ModelStatusComplete {
+		return nil, ErrModelIncomplete
+	}
+	if expired(model) {
+		return nil, ErrModelExpired
+	}
+	return s.activate(ctx, model, "promote")
+}
+
+// RollbackModel reactivates the version of a model that was active before
+// the current one. Rolling back twice returns to the current version.
+func (s *Service) RollbackModel(ctx context.Context, modelName string) (*models.Model, error) {
+	versions, err := s.pgStore.ListModels(ctx, modelName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+
+	var previous *models.Model
+	for i := range versions {
+		v := &versions[i]
+		if v.Active || v.ActivatedAt == nil || v.Status != models.ModelStatusComplete {
+			continue
+		}
+		if previous == nil || v.ActivatedAt.After(*previous.ActivatedAt) {
+			previous = v
+		}
+	}
+	if previous == nil {
+		return nil, ErrNoPreviousVersion
+	}
+	if expired(previous) {
+		return nil, ErrModelExpired
+	}
+	return s.activate(ctx, previous, "rollback")
+}
+
+// expired reports whether the Redis data of a model version has expired, so
+// that serving it would silently return no candidates
+func expired(model *models.Model) bool {
+	return model.ExpiresAt != nil && !time.Now().Before(*model.ExpiresAt)
+}
+
+// activate marks model active and wakes the loop that serves it
+func (s *Service) activate(ctx context.Context, model *models.Model, action string) (*models.Model, error) {
+	err := s.pgStore.ActivateModel(ctx, model.ID)
+	if errors.Is(err, store.ErrModelNotServable) {
+		// The version was complete when checked and never reverts to
+		// pending, so its data expired since
+		return nil, ErrModelExpired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate model: %w", err)
+	}
+	metrics.ModelActivations.WithLabelValues(model.ModelName, action).Inc()
+	logger.Info("Activated model",
+		zap.String("model", model.ModelName),
+		zap.String("version", model.Version),
+		zap.Int64("model_id", model.ID),
+		zap.String("action", action))
+
+	if model.ModelName == s.cfg.Recommendation.ANN.ModelName {
+		wake(s.reloadEmbeddings)
+	}
+	if model.ModelName == s.cfg.Recommendation.CF.ModelName {
+		wake(s.reloadCF)
+	}
+
+	activated, err := s.pgStore.GetModel(ctx, model.ModelName, model.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get model: %w", err)
+	}
+	return activated, nil
+}
+
+// wake signals a model loop without blocking; a pending signal is enough
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
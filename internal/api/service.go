@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/yourusername/reco-engine/internal/models"
 	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/cache"
 	"github.com/yourusername/reco-engine/internal/util/config"
 	"github.com/yourusername/reco-engine/internal/util/logger"
 	"github.com/yourusername/reco-engine/internal/util/metrics"
@@ -19,22 +22,45 @@ import (
 
 // Service handles recommendation logic
 type Service struct {
-	redisStore *store.RedisStore
-	pgStore    *store.PostgresStore
+	redisStore store.RecoSignalStore
+	pgStore    store.CatalogStore
+	itemCache  *cache.LRU[int64, *models.Item]
+	embeddings atomic.Pointer[embeddingModel]
+	cf         atomic.Pointer[cfModel]
 	cfg        *config.Config
+	now        func() time.Time
+
+	// Wake the model loops when a model is promoted through this service
+	reloadEmbeddings chan struct{}
+	reloadCF         chan struct{}
 }
 
 // NewService creates a new recommendation service
-func NewService(cfg *config.Config, redisStore *store.RedisStore, pgStore *store.PostgresStore) *Service {
+func NewService(cfg *config.Config, redisStore store.RecoSignalStore, pgStore store.CatalogStore) *Service {
 	return &Service{
 		redisStore: redisStore,
 		pgStore:    pgStore,
+		itemCache:  cache.NewLRU[int64, *models.Item](cfg.Recommendation.ItemCacheSize, cfg.Recommendation.ItemCacheTTL),
 		cfg:        cfg,
+		now:        time.Now,
+
+		reloadEmbeddings: make(chan struct{}, 1),
+		reloadCF:         make(chan struct{}, 1),
 	}
 }
 
-// GetRecommendations generates personalized recommendations for a user
-func (s *Service) GetRecommendations(ctx context.Context, userID int64, count int) (*models.RecommendationResponse, error) {
+// SetClock sets the time recommendations are generated at, which decides
+// seed recency and the purchase history window. Offline evaluation uses it to
+// recommend as of the end of the replayed events.
+func (s *Service) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// GetRecommendations generates personalized recommendations for a user.
+// A nil filter applies the configured default filter and allows cached
+// results; request-specific filters bypass the cache. The user's experiment
+// variants decide the scoring weights and candidate sources.
+func (s *Service) GetRecommendations(ctx context.Context, userID int64, count int, filter *Filter) (*models.RecommendationResponse, error) {
 	start := time.Now()
 	defer func() {
 		metrics.RecommendationLatency.WithLabelValues("personalized").Observe(time.Since(start).Seconds())
@@ -42,16 +68,24 @@ func (s *Service) GetRecommendations(ctx context.Context, userID int64, count in
 
 	metrics.RecommendationRequests.Inc()
 
-	// Try cache first
-	if cached, err := s.getCachedRecommendations(ctx, userID); err == nil {
-		metrics.RecommendationCacheHits.Inc()
-		logger.Debug("Cache hit for recommendations", zap.Int64("user_id", userID))
-		return cached, nil
+	useCache := filter == nil
+	if useCache {
+		defaultFilter := DefaultFilter(s.cfg.Recommendation.Filters)
+		filter = &defaultFilter
+
+		// Try cache first
+		if cached, err := s.getCachedRecommendations(ctx, userID); err == nil {
+			metrics.RecommendationCacheHits.Inc()
+			logger.Debug("Cache hit for recommendations", zap.Int64("user_id", userID))
+			recordExposures(cached, time.Since(start))
+			return cached, nil
+		}
+		metrics.RecommendationCacheMisses.Inc()
 	}
-	metrics.RecommendationCacheMisses.Inc()
 
 	// Generate recommendations
-	recommendations, err := s.generateRecommendations(ctx, userID, count)
+	strat := s.strategyFor(userID)
+	recommendations, err := s.generateRecommendations(ctx, userID, count, *filter, strat)
 	if err != nil {
 		return nil, err
 	}
@@ -59,10 +93,14 @@ func (s *Service) GetRecommendations(ctx context.Context, userID int64, count in
 	response := &models.RecommendationResponse{
 		UserID:          userID,
 		Recommendations: recommendations,
+		Experiments:     strat.assignments,
 	}
+	recordExposures(response, time.Since(start))
 
 	// Cache the result
-	go s.cacheRecommendations(context.Background(), userID, response)
+	if useCache {
+		go s.cacheRecommendations(context.Background(), userID, response)
+	}
 
 	return response, nil
 }
@@ -74,8 +112,16 @@ func (s *Service) GetPopularItems(ctx context.Context, category string, count in
 		metrics.RecommendationLatency.WithLabelValues("popular").Observe(time.Since(start).Seconds())
 	}()
 
-	// Get popular items from Redis
-	popularItems, err := s.redisStore.GetPopularItems(ctx, count*2) // Get more for filtering
+	// Get popular items from Redis, per category when filtered
+	var (
+		popularItems []redis.Z
+		err          error
+	)
+	if category != "" {
+		popularItems, err = s.redisStore.GetCategoryPopularItems(ctx, category, count)
+	} else {
+		popularItems, err = s.redisStore.GetPopularItems(ctx, count)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get popular items: %w", err)
 	}
@@ -87,120 +133,376 @@ func (s *Service) GetPopularItems(ctx context.Context, category string, count in
 			continue
 		}
 
-		// If category filter, check item category
-		if category != "" {
-			item, err := s.pgStore.GetItem(ctx, itemID)
-			if err != nil || item.Category != category {
+		recommendations = append(recommendations, models.Recommendation{
+			ItemID: itemID,
+			Score:  z.Score,
+			Reason: "popular",
+		})
+	}
+
+	return recommendations, nil
+}
+
+// GetSimilarItems returns items related to the given item, blending co-view
+// and KNN neighbours. Items are filtered by the default filter, and a
+// non-empty category restricts results to that category.
+func (s *Service) GetSimilarItems(ctx context.Context, itemID int64, category string, count int) ([]models.Recommendation, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecommendationLatency.WithLabelValues("similar").Observe(time.Since(start).Seconds())
+	}()
+
+	strat := s.defaultStrategy()
+
+	// Fetch more neighbours than needed when filtering by category
+	if category != "" && count*3 > strat.neighbourLimit {
+		strat.neighbourLimit = count * 3
+	}
+
+	candidates := make(map[int64]*candidateScore)
+	s.expandSeed(ctx, strat, seedItem{itemID: itemID, weight: 1}, candidates, func(candID int64) bool {
+		return candID == itemID
+	})
+
+	filter := DefaultFilter(s.cfg.Recommendation.Filters)
+	filter.Category = category
+	if err := s.applyFilters(ctx, 0, candidates, filter); err != nil {
+		return nil, fmt.Errorf("failed to filter candidates: %w", err)
+	}
+
+	return s.rankCandidates(candidates, count, strat.weights), nil
+}
+
+// GetBoughtTogether returns items frequently bought together with the given
+// item, ranked by the sum of their co-purchase and co-cart scores. Out of
+// stock items are dropped when the default filter excludes them.
+func (s *Service) GetBoughtTogether(ctx context.Context, itemID int64, count int) ([]models.Recommendation, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecommendationLatency.WithLabelValues("bought_together").Observe(time.Since(start).Seconds())
+	}()
+
+	limit := count * 3
+	if limit < defaultNeighbourLimit {
+		limit = defaultNeighbourLimit
+	}
+
+	candidates := make(map[int64]*candidateScore)
+	for _, relation := range []string{store.RelationCoPurchase, store.RelationCoCart} {
+		related, err := s.redisStore.GetRelatedItems(ctx, relation, itemID, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s items: %w", relation, err)
+		}
+
+		for _, z := range related {
+			candItemID, err := strconv.ParseInt(z.Member.(string), 10, 64)
+			if err != nil || candItemID == itemID {
 				continue
 			}
+
+			if candidates[candItemID] == nil {
+				candidates[candItemID] = &candidateScore{}
+			}
+			if relation == store.RelationCoPurchase {
+				candidates[candItemID].copurchaseScore += z.Score
+			} else {
+				candidates[candItemID].cocartScore += z.Score
+			}
 		}
+	}
+
+	filter := Filter{ExcludeOutOfStock: s.cfg.Recommendation.Filters.ExcludeOutOfStock}
+	if err := s.applyFilters(ctx, 0, candidates, filter); err != nil {
+		return nil, fmt.Errorf("failed to filter candidates: %w", err)
+	}
 
+	recommendations := make([]models.Recommendation, 0, len(candidates))
+	for candItemID, scores := range candidates {
+		reason := "co_purchase"
+		if scores.cocartScore > scores.copurchaseScore {
+			reason = "co_cart"
+		}
 		recommendations = append(recommendations, models.Recommendation{
-			ItemID: itemID,
-			Score:  z.Score,
-			Reason: "popular",
+			ItemID: candItemID,
+			Score:  scores.copurchaseScore + scores.cocartScore,
+			Reason: reason,
 		})
+	}
 
-		if len(recommendations) >= count {
-			break
+	sort.Slice(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
 		}
+		return recommendations[i].ItemID < recommendations[j].ItemID
+	})
+	if len(recommendations) > count {
+		recommendations = recommendations[:count]
 	}
 
 	return recommendations, nil
 }
 
-func (s *Service) generateRecommendations(ctx context.Context, userID int64, count int) ([]models.Recommendation, error) {
+func (s *Service) generateRecommendations(ctx context.Context, userID int64, count int, filter Filter, strat strategy) ([]models.Recommendation, error) {
 	candidates := make(map[int64]*candidateScore)
 
 	// 1. Get user's recent items
-	recentItems, err := s.redisStore.GetRecentItems(ctx, userID, 5)
+	seeds := s.getRecentSeeds(ctx, userID, strat.seedCount)
+	recentItems := make([]string, len(seeds))
+	for i, seed := range seeds {
+		recentItems[i] = strconv.FormatInt(seed.itemID, 10)
+	}
+
+	// 2. Expand via co-view and KNN, weighted by how recently each seed was touched
+	for _, seed := range seeds {
+		s.expandSeed(ctx, strat, seed, candidates, func(candID int64) bool {
+			// Skip if in recent items
+			return s.isRecentItem(candID, recentItems)
+		})
+	}
+
+	// 3. Add the items users move on to from the most recent one
+	if strat.uses(sourceTransitions) && len(seeds) > 0 {
+		s.expandTransitions(ctx, strat, seeds[0], candidates, func(candID int64) bool {
+			return s.isRecentItem(candID, recentItems)
+		})
+	}
+
+	// 4. Add the items the user's collaborative filtering factors score highest
+	if strat.uses(sourceCF) {
+		s.expandCF(ctx, strat, userID, candidates, func(candID int64) bool {
+			return s.isRecentItem(candID, recentItems)
+		})
+	}
+
+	// 5. Apply business rules
+	if err := s.applyFilters(ctx, userID, candidates, filter); err != nil {
+		return nil, fmt.Errorf("failed to filter candidates: %w", err)
+	}
+
+	// 6. Top up with popular items, filtered the same way, when too few
+	// candidates are left
+	if strat.uses(sourcePopular) && len(candidates) < count {
+		if err := s.addPopular(ctx, userID, count, filter, recentItems, candidates); err != nil {
+			return nil, fmt.Errorf("failed to filter popular items: %w", err)
+		}
+	}
+
+	// 7. Score and rank candidates
+	return s.rankCandidates(candidates, count, strat.weights), nil
+}
+
+// addPopular adds popular items to candidates as a fallback, from the
+// category's popularity set when the filter restricts the category. Items not
+// yet among the candidates are filtered before they are added.
+func (s *Service) addPopular(ctx context.Context, userID int64, count int, filter Filter, recentItems []string, candidates map[int64]*candidateScore) error {
+	var (
+		popularItems []redis.Z
+		err          error
+	)
+	if filter.Category != "" {
+		popularItems, err = s.redisStore.GetCategoryPopularItems(ctx, filter.Category, count*2)
+	} else {
+		popularItems, err = s.redisStore.GetPopularItems(ctx, count*2)
+	}
 	if err != nil {
-		logger.Warn("Failed to get recent items", zap.Error(err))
+		// Popular items are only a fallback; rank what there is without them
+		return nil
 	}
 
-	// 2. Expand via co-view
-	for _, itemStr := range recentItems {
-		itemID, err := strconv.ParseInt(itemStr, 10, 64)
+	added := make(map[int64]*candidateScore)
+	for _, z := range popularItems {
+		itemID, err := strconv.ParseInt(z.Member.(string), 10, 64)
 		if err != nil {
 			continue
 		}
 
-		// Get co-viewed items
-		coViewItems, err := s.redisStore.GetCoViewItems(ctx, itemID, 20)
-		if err != nil {
-			logger.Warn("Failed to get co-view items", zap.Error(err))
+		if s.isRecentItem(itemID, recentItems) {
 			continue
 		}
 
-		for _, z := range coViewItems {
-			candItemID, err := strconv.ParseInt(z.Member.(string), 10, 64)
+		if candidates[itemID] != nil {
+			candidates[itemID].popularityScore += z.Score
+			continue
+		}
+		added[itemID] = &candidateScore{popularityScore: z.Score}
+	}
+
+	if err := s.applyFilters(ctx, userID, added, filter); err != nil {
+		return err
+	}
+	for itemID, scores := range added {
+		candidates[itemID] = scores
+	}
+	return nil
+}
+
+// seedItem is a recent item used to expand candidates. recency is in [0, 1]
+// and halves every recency_half_life since the user touched the item; weight
+// scales the seed's co-view and KNN contributions, and is its recency unless
+// the age of the seed is unknown or recency decay is off.
+type seedItem struct {
+	itemID  int64
+	recency float64
+	weight  float64
+}
+
+// getRecentSeeds returns the user's most recent distinct items with their
+// recency weight. Users without touch times fall back to the recent items
+// list with no recency weight.
+func (s *Service) getRecentSeeds(ctx context.Context, userID int64, count int) []seedItem {
+	touched, err := s.redisStore.GetRecentItemTimes(ctx, userID, count)
+	if err != nil {
+		logger.Warn("Failed to get recent item times", zap.Error(err))
+	}
+
+	var seeds []seedItem
+	if len(touched) > 0 {
+		now := s.now()
+		for _, z := range touched {
+			itemID, err := strconv.ParseInt(z.Member.(string), 10, 64)
 			if err != nil {
 				continue
 			}
-
-			// Skip if in recent items
-			if s.isRecentItem(candItemID, recentItems) {
-				continue
+			age := now.Sub(time.UnixMilli(int64(z.Score)))
+			seed := seedItem{itemID: itemID, recency: s.recencyWeight(age), weight: 1}
+			if s.cfg.Recommendation.RecencyHalfLife > 0 {
+				seed.weight = seed.recency
 			}
+			seeds = append(seeds, seed)
+		}
+		return seeds
+	}
 
-			if candidates[candItemID] == nil {
-				candidates[candItemID] = &candidateScore{}
-			}
-			candidates[candItemID].coviewScore += z.Score
+	recentItems, err := s.redisStore.GetRecentItems(ctx, userID, count)
+	if err != nil {
+		logger.Warn("Failed to get recent items", zap.Error(err))
+	}
+	for _, itemStr := range recentItems {
+		if itemID, err := strconv.ParseInt(itemStr, 10, 64); err == nil {
+			seeds = append(seeds, seedItem{itemID: itemID, weight: 1})
 		}
+	}
+	return seeds
+}
+
+// recencyWeight decays exponentially with the configured half-life
+func (s *Service) recencyWeight(age time.Duration) float64 {
+	halfLife := s.cfg.Recommendation.RecencyHalfLife
+	if halfLife <= 0 {
+		return 0
+	}
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
 
-		// Get KNN items (from offline model)
-		knnItems, err := s.redisStore.GetItemKNN(ctx, itemID, 20)
+// expandSeed adds the co-view and KNN neighbours of a seed item to candidates,
+// using the sources enabled by the strategy and ignoring items for which skip
+// returns true. KNN neighbours come from the embedding index when it covers
+// the seed, scored by cosine similarity, and otherwise from the precomputed
+// lists of the served embedding model. Neighbour scores are scaled by the
+// seed's weight, and each neighbour's recency score is the highest recency
+// among the seeds that reach it.
+func (s *Service) expandSeed(ctx context.Context, strat strategy, seed seedItem, candidates map[int64]*candidateScore, skip func(int64) bool) {
+	seedID, limit := seed.itemID, strat.neighbourLimit
+
+	// Get co-viewed items
+	var coViewItems []redis.Z
+	if strat.uses(sourceCoview) {
+		var err error
+		coViewItems, err = s.redisStore.GetRelatedItems(ctx, store.RelationCoView, seedID, limit)
 		if err != nil {
-			logger.Warn("Failed to get KNN items", zap.Error(err))
+			logger.Warn("Failed to get co-view items", zap.Error(err))
+		}
+	}
+
+	for _, z := range coViewItems {
+		candItemID, err := strconv.ParseInt(z.Member.(string), 10, 64)
+		if err != nil || skip(candItemID) {
 			continue
 		}
 
-		for idx, knnItemStr := range knnItems {
-			knnItemID, err := strconv.ParseInt(knnItemStr, 10, 64)
-			if err != nil {
-				continue
-			}
+		if candidates[candItemID] == nil {
+			candidates[candItemID] = &candidateScore{}
+		}
+		candidates[candItemID].coviewScore += seed.weight * z.Score
+		candidates[candItemID].recencyScore = math.Max(candidates[candItemID].recencyScore, seed.recency)
+	}
 
-			// Skip if in recent items
-			if s.isRecentItem(knnItemID, recentItems) {
+	if !strat.uses(sourceKNN) {
+		return
+	}
+
+	if neighbours, ok := s.embeddingNeighbours(seedID, limit); ok {
+		for _, n := range neighbours {
+			if n.Score <= 0 || skip(n.ID) {
 				continue
 			}
 
-			if candidates[knnItemID] == nil {
-				candidates[knnItemID] = &candidateScore{}
+			if candidates[n.ID] == nil {
+				candidates[n.ID] = &candidateScore{}
 			}
-			// Higher score for higher ranked items
-			candidates[knnItemID].embeddingScore += float64(20-idx) / 20.0
+			candidates[n.ID].embeddingScore += seed.weight * n.Score
+			candidates[n.ID].recencyScore = math.Max(candidates[n.ID].recencyScore, seed.recency)
 		}
+		return
 	}
 
-	// 3. Add popular items as fallback
-	if len(candidates) < count {
-		popularItems, err := s.redisStore.GetPopularItems(ctx, count*2)
-		if err == nil {
-			for _, z := range popularItems {
-				itemID, err := strconv.ParseInt(z.Member.(string), 10, 64)
-				if err != nil {
-					continue
-				}
-
-				if s.isRecentItem(itemID, recentItems) {
-					continue
-				}
-
-				if candidates[itemID] == nil {
-					candidates[itemID] = &candidateScore{}
-				}
-				candidates[itemID].popularityScore += z.Score
-			}
+	// Get KNN items (from offline model)
+	served := s.embeddings.Load()
+	if served == nil {
+		return
+	}
+	knnItems, err := s.redisStore.GetItemKNN(ctx, served.modelID, seedID, limit)
+	if err != nil {
+		logger.Warn("Failed to get KNN items", zap.Error(err))
+		return
+	}
+
+	for idx, knnItemStr := range knnItems {
+		knnItemID, err := strconv.ParseInt(knnItemStr, 10, 64)
+		if err != nil || skip(knnItemID) {
+			continue
 		}
+
+		if candidates[knnItemID] == nil {
+			candidates[knnItemID] = &candidateScore{}
+		}
+		// Higher score for higher ranked items
+		candidates[knnItemID].embeddingScore += seed.weight * float64(limit-idx) / float64(limit)
+		candidates[knnItemID].recencyScore = math.Max(candidates[knnItemID].recencyScore, seed.recency)
+	}
+}
+
+// expandTransitions adds the items that users most often moved on to from
+// the seed item, ignoring items for which skip returns true
+func (s *Service) expandTransitions(ctx context.Context, strat strategy, seed seedItem, candidates map[int64]*candidateScore, skip func(int64) bool) {
+	next, err := s.redisStore.GetRelatedItems(ctx, store.RelationTransition, seed.itemID, strat.neighbourLimit)
+	if err != nil {
+		logger.Warn("Failed to get transition items", zap.Error(err))
+		return
 	}
 
-	// 4. Score and rank candidates
+	for _, z := range next {
+		candItemID, err := strconv.ParseInt(z.Member.(string), 10, 64)
+		if err != nil || candItemID == seed.itemID || skip(candItemID) {
+			continue
+		}
+
+		if candidates[candItemID] == nil {
+			candidates[candItemID] = &candidateScore{}
+		}
+		candidates[candItemID].transitionScore += z.Score
+		candidates[candItemID].recencyScore = math.Max(candidates[candItemID].recencyScore, seed.recency)
+	}
+}
+
+// rankCandidates scores candidates and returns the top count by score
+func (s *Service) rankCandidates(candidates map[int64]*candidateScore, count int, weights config.WeightsConfig) []models.Recommendation {
 	var recommendations []models.Recommendation
 	for itemID, scores := range candidates {
-		finalScore := s.calculateFinalScore(scores)
+		finalScore := s.calculateFinalScore(scores, weights)
 		reason := s.determineReason(scores)
 
 		recommendations = append(recommendations, models.Recommendation{
@@ -220,7 +522,7 @@ func (s *Service) generateRecommendations(ctx context.Context, userID int64, cou
 		recommendations = recommendations[:count]
 	}
 
-	return recommendations, nil
+	return recommendations
 }
 
 type candidateScore struct {
@@ -228,17 +530,30 @@ type candidateScore struct {
 	embeddingScore  float64
 	popularityScore float64
 	recencyScore    float64
+	transitionScore float64
+	cfScore         float64
+	copurchaseScore float64
+	cocartScore     float64
 }
 
-func (s *Service) calculateFinalScore(scores *candidateScore) float64 {
-	weights := s.cfg.Recommendation.Weights
+func (s *Service) calculateFinalScore(scores *candidateScore, weights config.WeightsConfig) float64 {
 	return scores.coviewScore*weights.Coview +
 		scores.embeddingScore*weights.Embedding +
 		scores.popularityScore*weights.Popularity +
-		scores.recencyScore*weights.Recency
+		scores.recencyScore*weights.Recency +
+		scores.transitionScore*weights.Transition +
+		scores.cfScore*weights.CF
 }
 
 func (s *Service) determineReason(scores *candidateScore) string {
+	if scores.cfScore > scores.transitionScore && scores.cfScore > scores.coviewScore &&
+		scores.cfScore > scores.embeddingScore && scores.cfScore > scores.popularityScore {
+		return "collaborative"
+	}
+	if scores.transitionScore > scores.coviewScore && scores.transitionScore > scores.embeddingScore &&
+		scores.transitionScore > scores.popularityScore {
+		return "next_item"
+	}
 	if scores.coviewScore > scores.embeddingScore && scores.coviewScore > scores.popularityScore {
 		return "co_view"
 	}
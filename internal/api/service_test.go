@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+func newTestService(t *testing.T, cfg *config.Config) (*Service, *store.RedisStore) {
+	mr := miniredis.RunT(t)
+	redisStore, err := store.NewRedisStore(config.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisStore.Close() })
+
+	return NewService(cfg, redisStore, nil), redisStore
+}
+
+func TestGenerateRecommendations_RecentSeedsWeighMore(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Recommendation: config.RecommendationConfig{
+		RecencyHalfLife: 30 * time.Minute,
+		Weights:         config.WeightsConfig{Coview: 0.4, Recency: 0.1},
+	}}
+	svc, redisStore := newTestService(t, cfg)
+
+	const userID = 1
+	now := time.Now()
+
+	// Seed 100 was touched two hours ago, seed 200 just now
+	batch := store.NewSignalBatch(50, 0)
+	batch.AddRecentItem(userID, store.RecentItem{ItemID: 100, EventType: models.EventTypeView, TouchedAt: now.Add(-2 * time.Hour)})
+	batch.AddRecentItem(userID, store.RecentItem{ItemID: 200, EventType: models.EventTypeView, TouchedAt: now})
+
+	// Both seeds have one equally strong co-view neighbour
+	batch.AddRelated(store.RelationCoView, 100, 101, 1)
+	batch.AddRelated(store.RelationCoView, 200, 201, 1)
+	require.NoError(t, redisStore.ApplySignalBatch(ctx, batch))
+
+	recs, err := svc.generateRecommendations(ctx, userID, 10, Filter{}, svc.defaultStrategy())
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	assert.Equal(t, int64(201), recs[0].ItemID)
+	assert.Equal(t, int64(101), recs[1].ItemID)
+
+	// 0.4*1 + 0.1*1 for the fresh seed, (0.4*1 + 0.1)*(1/16) for the old one
+	assert.InDelta(t, 0.5, recs[0].Score, 1e-3)
+	assert.InDelta(t, 0.03125, recs[1].Score, 1e-3)
+}
+
+func TestGetBoughtTogether_CombinesPurchasesAndCarts(t *testing.T) {
+	ctx := context.Background()
+	svc, redisStore := newTestService(t, &config.Config{})
+
+	batch := store.NewSignalBatch(50, 0)
+	batch.AddRelated(store.RelationCoPurchase, 1, 2, 10)
+	batch.AddRelated(store.RelationCoPurchase, 1, 3, 10)
+	batch.AddRelated(store.RelationCoCart, 1, 3, 3)
+	batch.AddRelated(store.RelationCoCart, 1, 4, 6)
+	batch.AddRelated(store.RelationCoView, 1, 5, 100)
+	require.NoError(t, redisStore.ApplySignalBatch(ctx, batch))
+
+	recs, err := svc.GetBoughtTogether(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Recommendation{
+		{ItemID: 3, Score: 13, Reason: "co_purchase"},
+		{ItemID: 2, Score: 10, Reason: "co_purchase"},
+		{ItemID: 4, Score: 6, Reason: "co_cart"},
+	}, recs)
+}
+
+func TestGenerateRecommendations_TransitionsFromLatestItem(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Recommendation: config.RecommendationConfig{
+		Weights: config.WeightsConfig{Coview: 0.4, Transition: 0.3},
+	}}
+	svc, redisStore := newTestService(t, cfg)
+
+	const userID = 1
+	now := time.Now()
+	batch := store.NewSignalBatch(50, 0)
+	batch.AddRecentItem(userID, store.RecentItem{ItemID: 100, EventType: models.EventTypeView, TouchedAt: now.Add(-time.Hour)})
+	batch.AddRecentItem(userID, store.RecentItem{ItemID: 200, EventType: models.EventTypeView, TouchedAt: now})
+
+	// Only the latest item seeds transitions, and they are directed
+	batch.AddRelated(store.RelationTransition, 200, 201, 4)
+	batch.AddRelated(store.RelationTransition, 100, 101, 4)
+	batch.AddRelated(store.RelationTransition, 202, 200, 4)
+	batch.AddRelated(store.RelationCoView, 200, 203, 1)
+	require.NoError(t, redisStore.ApplySignalBatch(ctx, batch))
+
+	recs, err := svc.generateRecommendations(ctx, userID, 10, Filter{}, svc.defaultStrategy())
+	require.NoError(t, err)
+	assert.Equal(t, []models.Recommendation{
+		{ItemID: 201, Score: 1.2, Reason: "next_item"},
+		{ItemID: 203, Score: 0.4, Reason: "co_view"},
+	}, recs)
+}
+
+func TestGetSimilarItems_BlendsCoViewAndEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	signals := store.NewMemorySignalStore()
+	catalog := store.NewMemoryCatalogStore(
+		models.Item{ID: 1, Category: "electronics"},
+		models.Item{ID: 2, Category: "electronics"},
+		models.Item{ID: 3, Category: "books"},
+		models.Item{ID: 4, Category: "books"},
+	)
+	cfg := &config.Config{Recommendation: config.RecommendationConfig{
+		Weights: config.WeightsConfig{Coview: 0.5, Embedding: 0.5},
+		ANN:     config.ANNConfig{ModelName: "item2vec"},
+	}}
+	svc := NewService(cfg, signals, catalog)
+
+	batch := store.NewSignalBatch(50, 0)
+	batch.AddRelated(store.RelationCoView, 1, 2, 4)
+	batch.AddRelated(store.RelationCoView, 1, 3, 2)
+	require.NoError(t, signals.ApplySignalBatch(ctx, batch))
+
+	// Without the index, KNN neighbours come from the model's lists
+	model := &models.Model{ModelName: "item2vec", Version: "v1"}
+	require.NoError(t, catalog.InsertModel(ctx, model))
+	require.NoError(t, signals.SetItemKNN(ctx, model.ID, 1, []int64{3, 4, 1}))
+	require.NoError(t, svc.refreshEmbeddingModel(ctx))
+
+	// Co-view scores, plus KNN scores of (20 - rank) / 20
+	recs, err := svc.GetSimilarItems(ctx, 1, "", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Recommendation{
+		{ItemID: 2, Score: 2, Reason: "co_view"},
+		{ItemID: 3, Score: 1.5, Reason: "co_view"},
+		{ItemID: 4, Score: 0.475, Reason: "embedding"},
+	}, recs, "the item itself is not similar to itself")
+
+	recs, err = svc.GetSimilarItems(ctx, 1, "books", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Recommendation{
+		{ItemID: 3, Score: 1.5, Reason: "co_view"},
+		{ItemID: 4, Score: 0.475, Reason: "embedding"},
+	}, recs)
+
+	recs, err = svc.GetSimilarItems(ctx, 1, "books", 1)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, int64(3), recs[0].ItemID)
+}
+
+func TestGenerateRecommendations_TopsUpFilteredCandidatesFromPopularity(t *testing.T) {
+	ctx := context.Background()
+	signals := store.NewMemorySignalStore()
+	catalog := store.NewMemoryCatalogStore(
+		models.Item{ID: 100, Category: "electronics"},
+		models.Item{ID: 101, Category: "electronics"},
+		models.Item{ID: 102, Category: "electronics"},
+		models.Item{ID: 103, Category: "electronics"},
+		models.Item{ID: 104, Category: "books"},
+		models.Item{ID: 105, Category: "books"},
+		models.Item{ID: 106, Category: "books"},
+	)
+	cfg := &config.Config{Recommendation: config.RecommendationConfig{
+		Weights: config.WeightsConfig{Coview: 1, Popularity: 0.1},
+	}}
+	svc := NewService(cfg, signals, catalog)
+
+	const userID = 1
+	batch := store.NewSignalBatch(50, 0)
+	batch.AddRecentItem(userID, store.RecentItem{ItemID: 100, EventType: models.EventTypeView, TouchedAt: time.Now()})
+
+	// Enough co-view candidates to skip the fallback before filtering, but
+	// only one of them is a book
+	for _, itemID := range []int64{101, 102, 103, 104} {
+		batch.AddRelated(store.RelationCoView, 100, itemID, 1)
+	}
+
+	// The globally popular items are all electronics
+	for _, itemID := range []int64{101, 102, 103} {
+		batch.AddPopularity(itemID, 100)
+	}
+	batch.AddCategoryPopularity("books", 105, 5)
+	batch.AddCategoryPopularity("books", 106, 2)
+	require.NoError(t, signals.ApplySignalBatch(ctx, batch))
+
+	recs, err := svc.generateRecommendations(ctx, userID, 3, Filter{Category: "books"}, svc.defaultStrategy())
+	require.NoError(t, err)
+	assert.Equal(t, []models.Recommendation{
+		{ItemID: 104, Score: 1, Reason: "co_view"},
+		{ItemID: 105, Score: 0.5, Reason: "popular"},
+		{ItemID: 106, Score: 0.2, Reason: "popular"},
+	}, recs)
+}
+
+func TestGetSimilarItems_AppliesDefaultFilter(t *testing.T) {
+	ctx := context.Background()
+	signals := store.NewMemorySignalStore()
+	catalog := store.NewMemoryCatalogStore(
+		models.Item{ID: 1, Category: "books", Stock: 1},
+		models.Item{ID: 2, Category: "books", Stock: 0},
+		models.Item{ID: 3, Category: "books", Stock: 4},
+		models.Item{ID: 4, Category: "electronics", Stock: 2},
+	)
+	cfg := &config.Config{Recommendation: config.RecommendationConfig{
+		Weights: config.WeightsConfig{Coview: 1},
+		Filters: config.FilterConfig{ExcludeOutOfStock: true},
+	}}
+	svc := NewService(cfg, signals, catalog)
+
+	batch := store.NewSignalBatch(50, 0)
+	batch.AddRelated(store.RelationCoView, 1, 2, 3)
+	batch.AddRelated(store.RelationCoView, 1, 3, 2)
+	batch.AddRelated(store.RelationCoView, 1, 4, 1)
+	require.NoError(t, signals.ApplySignalBatch(ctx, batch))
+
+	recs, err := svc.GetSimilarItems(ctx, 1, "books", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Recommendation{
+		{ItemID: 3, Score: 2, Reason: "co_view"},
+	}, recs, "out of stock and other categories are dropped")
+}
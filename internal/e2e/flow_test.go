@@ -0,0 +1,134 @@
+package e2e_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/api"
+	"github.com/yourusername/reco-engine/internal/ingest"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/processor"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/stream"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestIngestProcessRecommend runs events through ingest, the processor and the
+// recommendation API using only in-memory stores and topics.
+func TestIngestProcessRecommend(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg, err := config.Load("../../config/config.yaml")
+	require.NoError(t, err)
+	require.NoError(t, logger.Init("error", "console"))
+
+	_, err = tracing.Init(ctx, cfg.Observability.Tracing, "e2e")
+	require.NoError(t, err)
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+
+	signals := store.NewMemorySignalStore()
+	catalog := store.NewMemoryCatalogStore(
+		models.Item{ID: 1, Category: "books", Stock: 5},
+		models.Item{ID: 2, Category: "books", Stock: 5},
+		models.Item{ID: 3, Category: "books", Stock: 5},
+		models.Item{ID: 4, Category: "books", Stock: 0},
+	)
+	events := stream.NewMemoryTopic(cfg.Kafka.Topics.Events)
+	defer events.Close()
+	dlq := stream.NewMemoryTopic(cfg.Kafka.Topics.DeadLetter)
+	defer dlq.Close()
+
+	ingestSvc := ingest.NewService(cfg, events, catalog, signals)
+	processorSvc := processor.NewService(cfg, events, dlq, signals, catalog)
+	apiSvc := api.NewService(cfg, signals, catalog)
+
+	done := make(chan error, 1)
+	go func() { done <- processorSvc.Start(ctx) }()
+
+	// User 1 browses all four items, user 2 only the first one
+	now := time.Now()
+	var batch []models.Event
+	for i, itemID := range []int64{1, 2, 3, 4} {
+		batch = append(batch, models.Event{
+			EventID:   fmt.Sprintf("u1-%d", itemID),
+			UserID:    1,
+			ItemID:    itemID,
+			EventType: models.EventTypeView,
+			Timestamp: now.Add(time.Duration(i-10) * time.Second),
+		})
+	}
+	resp, err := ingestSvc.IngestEvents(ctx, batch)
+	require.NoError(t, err)
+	assert.Equal(t, 4, resp.Accepted)
+
+	require.NoError(t, ingestSvc.IngestEvent(ctx, &models.Event{
+		EventID:   "u2-1",
+		UserID:    2,
+		ItemID:    1,
+		EventType: models.EventTypeView,
+	}))
+
+	// Client retries are dropped at ingest
+	err = ingestSvc.IngestEvent(ctx, &models.Event{
+		EventID:   "u2-1",
+		UserID:    2,
+		ItemID:    1,
+		EventType: models.EventTypeView,
+	})
+	assert.ErrorIs(t, err, ingest.ErrDuplicateEvent)
+
+	require.Eventually(t, func() bool {
+		return events.Committed() == 5
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, dlq.Messages())
+	require.Eventually(t, func() bool {
+		return len(catalog.Events()) == 5
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// User 2 gets the items user 1 co-viewed with item 1, minus the item
+	// user 2 already saw and the out-of-stock one
+	recs, err := apiSvc.GetRecommendations(ctx, 2, 10, nil)
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, rec := range recs.Recommendations {
+		ids = append(ids, rec.ItemID)
+	}
+	assert.ElementsMatch(t, []int64{2, 3}, ids)
+
+	popular, err := apiSvc.GetPopularItems(ctx, "books", 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, popular)
+	assert.Equal(t, int64(1), popular[0].ItemID)
+
+	// Each processed event continues the trace of its publish span
+	publishes := make(map[trace.TraceID]bool)
+	var processed []sdktrace.ReadOnlySpan
+	for _, span := range spans.Ended() {
+		switch span.Name() {
+		case cfg.Kafka.Topics.Events + " publish":
+			publishes[span.SpanContext().TraceID()] = true
+		case "processEvent":
+			processed = append(processed, span)
+		}
+	}
+	require.Len(t, processed, 5)
+	for _, span := range processed {
+		assert.True(t, publishes[span.SpanContext().TraceID()], "processEvent span is not linked to a publish span")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
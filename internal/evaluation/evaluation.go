@@ -0,0 +1,197 @@
+// Package evaluation measures recommendation quality offline. Events before
+// a split time are replayed through the processor into in-memory stores, the
+// recommender is asked for every user active after the split, and the
+// recommendations are compared with the items those users went on to
+// interact with.
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yourusername/reco-engine/internal/api"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/processor"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+// ErrNoTestUsers is returned when no user has a target event after the split
+var ErrNoTestUsers = errors.New("no users with events after the split")
+
+// Options controls an evaluation run
+type Options struct {
+	SplitAt     time.Time     // events before it are replayed, events after it are held out
+	TrainWindow time.Duration // replay events logged within this duration before the split
+	TestWindow  time.Duration // hold out events logged within this duration after the split
+	K           int           // recommendations per user
+	Targets     []string      // event types that count as relevant after the split; all when empty
+	MaxUsers    int           // evaluate at most this many held-out users, by ID; 0 for all
+}
+
+// Run evaluates the recommender configured by cfg on the events logged in
+// catalog around the split, which are streamed rather than loaded at once.
+// The served versions of the offline models (embeddings, ALS) are loaded
+// from catalog, with their KNN lists and user factors read from modelData;
+// when modelData is nil they are not loaded. Experiments are ignored, so
+// every user gets the global configuration. The report notes what differs
+// from production.
+func Run(ctx context.Context, cfg *config.Config, catalog store.CatalogStore, modelData store.RecoSignalStore, opts Options) (*Report, error) {
+	if opts.K < 1 {
+		opts.K = 10
+	}
+	evalCfg := *cfg
+	evalCfg.Experiments = nil
+
+	relevant, testEvents, err := heldOut(ctx, catalog, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test events: %w", err)
+	}
+	userIDs := make([]int64, 0, len(relevant))
+	for userID := range relevant {
+		userIDs = append(userIDs, userID)
+	}
+	if len(userIDs) == 0 {
+		return nil, ErrNoTestUsers
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	if opts.MaxUsers > 0 && len(userIDs) > opts.MaxUsers {
+		userIDs = userIDs[:opts.MaxUsers]
+	}
+
+	// Build the signals as the processor would have at the split
+	signals := store.NewMemorySignalStore()
+	proc := processor.NewService(&evalCfg, nil, nil, signals, catalog)
+	popularity := newItemPopularity()
+	trainEvents := 0
+	err = proc.Replay(ctx, opts.SplitAt, func(fn func(*models.Event) error) error {
+		return catalog.ScanEvents(ctx, opts.SplitAt.Add(-opts.TrainWindow), opts.SplitAt, func(event *models.Event) error {
+			trainEvents++
+			popularity.add(event)
+			return fn(event)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay training events: %w", err)
+	}
+
+	notes := []string{"experiments are ignored; every user gets the global recommendation config"}
+	var served map[string]*models.Model
+	var recoSignals store.RecoSignalStore = signals
+	if modelData != nil {
+		recoSignals = modelSignals{RecoSignalStore: signals, models: modelData}
+	}
+	reco := api.NewService(&evalCfg, recoSignals, catalog)
+	reco.SetClock(func() time.Time { return opts.SplitAt })
+	if modelData != nil {
+		served, err = reco.LoadModels(ctx)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		notes = append(notes, "offline models (embeddings, KNN lists, collaborative filtering) are not loaded")
+	}
+	filter := api.DefaultFilter(evalCfg.Recommendation.Filters)
+
+	s := newScorer(opts.K, popularity.items, len(popularity.users))
+	for _, userID := range userIDs {
+		resp, err := reco.GetRecommendations(ctx, userID, opts.K, &filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recommend for user %d: %w", userID, err)
+		}
+		recs := make([]int64, len(resp.Recommendations))
+		for i, rec := range resp.Recommendations {
+			recs[i] = rec.ItemID
+		}
+		s.add(recs, relevant[userID])
+	}
+
+	report := s.report()
+	report.TrainEvents = trainEvents
+	report.TestEvents = testEvents
+	for name, model := range served {
+		if report.Models == nil {
+			report.Models = make(map[string]string)
+		}
+		report.Models[name] = model.Version
+		if model.CreatedAt.After(opts.SplitAt) {
+			notes = append(notes, fmt.Sprintf("model %s version %s was trained after the split and may have seen held-out events", name, model.Version))
+		}
+	}
+	sort.Strings(notes[1:])
+	report.Notes = notes
+	return report, nil
+}
+
+// modelSignals serves the offline models' KNN lists and user factors from
+// models and all other signals from the replayed store
+type modelSignals struct {
+	store.RecoSignalStore
+	models store.RecoSignalStore
+}
+
+func (m modelSignals) GetItemKNN(ctx context.Context, modelID, itemID int64, count int) ([]string, error) {
+	return m.models.GetItemKNN(ctx, modelID, itemID, count)
+}
+
+func (m modelSignals) GetUserFactors(ctx context.Context, modelID, userID int64) ([]float64, error) {
+	return m.models.GetUserFactors(ctx, modelID, userID)
+}
+
+// heldOut returns the items each user interacted with through a target event
+// after the split, and the number of events after the split
+func heldOut(ctx context.Context, catalog store.CatalogStore, opts Options) (map[int64]map[int64]bool, int, error) {
+	isTarget := make(map[string]bool, len(opts.Targets))
+	for _, t := range opts.Targets {
+		isTarget[t] = true
+	}
+
+	relevant := make(map[int64]map[int64]bool)
+	events := 0
+	err := catalog.ScanEvents(ctx, opts.SplitAt, opts.SplitAt.Add(opts.TestWindow), func(event *models.Event) error {
+		events++
+		if event.UserID == 0 || event.ItemID == 0 {
+			return nil
+		}
+		if len(isTarget) > 0 && !isTarget[event.EventType] {
+			return nil
+		}
+		if relevant[event.UserID] == nil {
+			relevant[event.UserID] = make(map[int64]bool)
+		}
+		relevant[event.UserID][event.ItemID] = true
+		return nil
+	})
+	return relevant, events, err
+}
+
+// itemPopularity counts the distinct users per item and the distinct users
+// in the training events
+type itemPopularity struct {
+	seen  map[[2]int64]bool
+	users map[int64]bool
+	items map[int64]int
+}
+
+func newItemPopularity() *itemPopularity {
+	return &itemPopularity{
+		seen:  make(map[[2]int64]bool),
+		users: make(map[int64]bool),
+		items: make(map[int64]int),
+	}
+}
+
+func (p *itemPopularity) add(event *models.Event) {
+	if event.ItemID == 0 {
+		return
+	}
+	p.users[event.UserID] = true
+	key := [2]int64{event.UserID, event.ItemID}
+	if !p.seen[key] {
+		p.seen[key] = true
+		p.items[event.ItemID]++
+	}
+}
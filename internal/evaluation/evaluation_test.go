@@ -0,0 +1,131 @@
+package evaluation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+)
+
+func TestScorer_Metrics(t *testing.T) {
+	s := newScorer(3, map[int64]int{1: 4, 2: 2, 3: 1, 4: 1, 6: 1}, 4)
+	s.add([]int64{1, 2, 3, 7}, map[int64]bool{2: true, 5: true})
+	s.add([]int64{4}, map[int64]bool{9: true})
+
+	r := s.report()
+	assert.Equal(t, 2, r.Users)
+	assert.InDelta(t, 1.0/6, r.Precision, 1e-9)
+	assert.InDelta(t, 0.25, r.Recall, 1e-9)
+	// (1/log2(3)) / (1 + 1/log2(3)) for the first user, 0 for the second
+	assert.InDelta(t, 0.3869/2, r.NDCG, 1e-4)
+	assert.InDelta(t, 0.25, r.MRR, 1e-9)
+	assert.InDelta(t, 0.5, r.HitRate, 1e-9)
+	assert.InDelta(t, 0.8, r.Coverage, 1e-9)
+	// -log2 of 4/4, 2/4, 1/4 and 1/4
+	assert.InDelta(t, 1.25, r.Novelty, 1e-9)
+
+	metrics := r.Metrics()
+	assert.Equal(t, 0.5, metrics["hit_rate"])
+	assert.Equal(t, float64(3), metrics["k"])
+}
+
+func TestRun_CoViewPredictsNextItem(t *testing.T) {
+	logger.Init("error", "console")
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{
+			BatchSize:        4,
+			RecentItemsLimit: 50,
+			CoviewWindow:     3,
+		},
+		Recommendation: config.RecommendationConfig{
+			Weights: config.WeightsConfig{Coview: 1},
+		},
+		EventWeights: config.EventWeightsConfig{View: 1, Purchase: 10},
+	}
+
+	split := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return split.Add(time.Duration(minutes) * time.Minute) }
+	catalog := store.NewMemoryCatalogStore()
+	view := func(userID, itemID int64, ts time.Time) {
+		require.NoError(t, catalog.InsertEvent(context.Background(), &models.Event{
+			UserID:    userID,
+			ItemID:    itemID,
+			EventType: models.EventTypeView,
+			SessionID: fmt.Sprintf("s%d", userID),
+			Timestamp: ts,
+		}))
+	}
+
+	// Users 1-5 view 10 then 20; user 6 views 30 then 40
+	for userID := int64(1); userID <= 5; userID++ {
+		view(userID, 10, at(-100+int(userID)))
+		view(userID, 20, at(-90+int(userID)))
+	}
+	view(6, 30, at(-80))
+	view(6, 40, at(-79))
+
+	// User 9 views 10 before the split and 20 after it; user 6 moves on to 50
+	view(9, 10, at(-10))
+	view(9, 20, at(5))
+	view(6, 50, at(6))
+
+	// Events outside the windows are not read
+	view(7, 10, at(-3*24*60))
+	view(7, 20, at(2*24*60))
+
+	opts := Options{SplitAt: split, TrainWindow: 24 * time.Hour, TestWindow: 24 * time.Hour, K: 2}
+	report, err := Run(context.Background(), cfg, catalog, nil, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, 13, report.TrainEvents)
+	assert.Equal(t, 2, report.TestEvents)
+	assert.Equal(t, 2, report.Users)
+	assert.InDelta(t, 0.5, report.HitRate, 1e-9, "user 9 gets 20, user 6 has no signal for 50")
+	assert.InDelta(t, 0.5, report.MRR, 1e-9)
+	assert.InDelta(t, 0.25, report.Precision, 1e-9)
+	assert.Empty(t, report.Models)
+	assert.Contains(t, report.Notes, "offline models (embeddings, KNN lists, collaborative filtering) are not loaded")
+
+	opts.SplitAt = at(10)
+	_, err = Run(context.Background(), cfg, catalog, nil, opts)
+	assert.ErrorIs(t, err, ErrNoTestUsers)
+}
+
+func TestRun_ServesOfflineModels(t *testing.T) {
+	logger.Init("error", "console")
+	ctx := context.Background()
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{BatchSize: 4, RecentItemsLimit: 50},
+		Recommendation: config.RecommendationConfig{
+			Weights: config.WeightsConfig{CF: 1},
+			CF:      config.CFConfig{Enabled: true, ModelName: "als"},
+		},
+		EventWeights: config.EventWeightsConfig{View: 1},
+	}
+
+	split := time.Now().Add(-time.Hour)
+	catalog := store.NewMemoryCatalogStore(models.Item{ID: 1}, models.Item{ID: 2})
+	require.NoError(t, catalog.InsertEvent(ctx, &models.Event{UserID: 7, ItemID: 1, EventType: models.EventTypeView, Timestamp: split.Add(-time.Minute)}))
+	require.NoError(t, catalog.InsertEvent(ctx, &models.Event{UserID: 7, ItemID: 2, EventType: models.EventTypeView, Timestamp: split.Add(time.Minute)}))
+
+	// The model was registered now, after the split
+	model := &models.Model{ModelName: "als", Version: "v1"}
+	require.NoError(t, catalog.InsertModel(ctx, model))
+	_, err := catalog.SaveItemEmbeddings(ctx, model.ID, map[int64][]float64{1: {1, 0}, 2: {0, 1}})
+	require.NoError(t, err)
+	modelData := store.NewMemorySignalStore()
+	require.NoError(t, modelData.SetUserFactors(ctx, model.ID, map[int64][]float64{7: {0, 1}}, 0))
+
+	report, err := Run(ctx, cfg, catalog, modelData, Options{SplitAt: split, TrainWindow: time.Hour, TestWindow: time.Hour, K: 1})
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, report.HitRate, 1e-9, "the user factors point at item 2")
+	assert.Equal(t, map[string]string{"als": "v1"}, report.Models)
+	assert.Contains(t, report.Notes, "model als version v1 was trained after the split and may have seen held-out events")
+}
@@ -0,0 +1,124 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Report holds ranking metrics averaged over the held-out users, plus
+// catalog-level metrics over all of their recommendations
+type Report struct {
+	K           int `json:"k"`
+	Users       int `json:"users"`
+	TrainEvents int `json:"train_events"`
+	TestEvents  int `json:"test_events"`
+
+	Precision float64 `json:"precision_at_k"`
+	Recall    float64 `json:"recall_at_k"`
+	NDCG      float64 `json:"ndcg_at_k"`
+	MRR       float64 `json:"mrr"`
+	HitRate   float64 `json:"hit_rate"`
+	Coverage  float64 `json:"catalog_coverage"` // share of training items recommended to anyone
+	Novelty   float64 `json:"novelty"`          // mean -log2 of the share of training users who had each recommended item
+
+	Models map[string]string `json:"models,omitempty"` // offline model versions served, by name
+	Notes  []string          `json:"notes,omitempty"`  // how the run differs from production
+}
+
+// Metrics returns the report in the form stored as models.Model.Metrics
+func (r *Report) Metrics() map[string]interface{} {
+	data, _ := json.Marshal(r)
+	var metrics map[string]interface{}
+	_ = json.Unmarshal(data, &metrics)
+	return metrics
+}
+
+// scorer accumulates the metrics of each user's top-k recommendations
+type scorer struct {
+	k           int
+	catalog     int           // items in the training events
+	popularity  map[int64]int // training users per item
+	trainUsers  int
+	recommended map[int64]bool
+
+	users                                   int
+	precision, recall, ndcg, mrr, hits, nov float64
+	novItems                                int
+}
+
+func newScorer(k int, popularity map[int64]int, trainUsers int) *scorer {
+	return &scorer{
+		k:           k,
+		catalog:     len(popularity),
+		popularity:  popularity,
+		trainUsers:  trainUsers,
+		recommended: make(map[int64]bool),
+	}
+}
+
+// add scores one user's ranked recommendations against the items the user
+// went on to interact with
+func (s *scorer) add(recs []int64, relevant map[int64]bool) {
+	if len(recs) > s.k {
+		recs = recs[:s.k]
+	}
+	s.users++
+
+	hits := 0
+	var dcg float64
+	firstHit := 0
+	for i, itemID := range recs {
+		n, known := s.popularity[itemID]
+		if known {
+			s.recommended[itemID] = true
+		}
+		if s.trainUsers > 0 {
+			s.nov -= math.Log2(float64(max(n, 1)) / float64(s.trainUsers))
+			s.novItems++
+		}
+
+		if relevant[itemID] {
+			hits++
+			dcg += 1 / math.Log2(float64(i+2))
+			if firstHit == 0 {
+				firstHit = i + 1
+			}
+		}
+	}
+
+	var idcg float64
+	for i := 0; i < min(len(relevant), s.k); i++ {
+		idcg += 1 / math.Log2(float64(i+2))
+	}
+
+	s.precision += float64(hits) / float64(s.k)
+	if len(relevant) > 0 {
+		s.recall += float64(hits) / float64(len(relevant))
+	}
+	if idcg > 0 {
+		s.ndcg += dcg / idcg
+	}
+	if firstHit > 0 {
+		s.mrr += 1 / float64(firstHit)
+		s.hits++
+	}
+}
+
+func (s *scorer) report() *Report {
+	r := &Report{K: s.k, Users: s.users}
+	if s.users > 0 {
+		n := float64(s.users)
+		r.Precision = s.precision / n
+		r.Recall = s.recall / n
+		r.NDCG = s.ndcg / n
+		r.MRR = s.mrr / n
+		r.HitRate = s.hits / n
+	}
+	if s.catalog > 0 {
+		r.Coverage = float64(len(s.recommended)) / float64(s.catalog)
+	}
+	if s.novItems > 0 {
+		r.Novelty = s.nov / float64(s.novItems)
+	}
+	return r
+}
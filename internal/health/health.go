@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Component and overall statuses reported by /readyz
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not_ready"
+)
+
+// defaultTimeout bounds each dependency check when none is configured
+const defaultTimeout = 2 * time.Second
+
+// CheckFunc reports whether a dependency is reachable
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	critical bool
+}
+
+// ComponentStatus is the result of a single dependency check
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the readiness response body
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// Checker runs dependency checks for the readiness endpoint
+type Checker struct {
+	checks  []check
+	timeout time.Duration
+}
+
+// NewChecker creates a checker whose checks each get at most timeout
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a dependency check. A failing critical check makes the
+// service not ready; a failing non-critical one only degrades it.
+func (h *Checker) Register(name string, fn CheckFunc, critical bool) {
+	h.checks = append(h.checks, check{name: name, fn: fn, critical: critical})
+}
+
+// Check runs all checks concurrently and aggregates their results
+func (h *Checker) Check(ctx context.Context) Report {
+	report := Report{
+		Status:     StatusReady,
+		Components: make(map[string]ComponentStatus, len(h.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, chk := range h.checks {
+		wg.Add(1)
+		go func(chk check) {
+			defer wg.Done()
+			status := h.run(ctx, chk)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[chk.name] = status
+			if status.Status == StatusDown {
+				if chk.critical {
+					report.Status = StatusNotReady
+				} else if report.Status == StatusReady {
+					report.Status = StatusDegraded
+				}
+			}
+		}(chk)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (h *Checker) run(ctx context.Context, chk check) ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() { errCh <- chk.fn(ctx) }()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		// Do not wait for checks that ignore their context
+		err = ctx.Err()
+	}
+
+	status := ComponentStatus{
+		Status:    StatusUp,
+		Critical:  chk.critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// HandleLivez handles GET /livez. It only reports that the process is
+// serving requests and never checks dependencies.
+func (h *Checker) HandleLivez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// HandleReadyz handles GET /readyz
+func (h *Checker) HandleReadyz(c *gin.Context) {
+	report := h.Check(c.Request.Context())
+
+	code := http.StatusOK
+	if report.Status == StatusNotReady {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
+
+// IsProbe reports whether r is a liveness or readiness probe, which should
+// not be traced or logged like regular traffic
+func IsProbe(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/livez", "/readyz", "/health":
+		return true
+	}
+	return false
+}
+
+// RegisterRoutes adds /livez and /readyz to the router
+func (h *Checker) RegisterRoutes(router gin.IRoutes) {
+	router.GET("/livez", h.HandleLivez)
+	router.GET("/readyz", h.HandleReadyz)
+}
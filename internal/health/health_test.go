@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func up(ctx context.Context) error { return nil }
+
+func down(ctx context.Context) error { return errors.New("connection refused") }
+
+func hang(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(c *Checker)
+		status   string
+	}{
+		{
+			name: "all up",
+			register: func(c *Checker) {
+				c.Register("redis", up, true)
+				c.Register("postgres", up, false)
+			},
+			status: StatusReady,
+		},
+		{
+			name: "optional dependency down",
+			register: func(c *Checker) {
+				c.Register("redis", up, true)
+				c.Register("postgres", down, false)
+			},
+			status: StatusDegraded,
+		},
+		{
+			name: "critical dependency times out",
+			register: func(c *Checker) {
+				c.Register("kafka", hang, true)
+				c.Register("postgres", down, false)
+			},
+			status: StatusNotReady,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(50 * time.Millisecond)
+			tt.register(checker)
+
+			report := checker.Check(context.Background())
+			assert.Equal(t, tt.status, report.Status)
+			assert.Len(t, report.Components, 2)
+		})
+	}
+}
+
+func TestHandleReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	checker := NewChecker(50 * time.Millisecond)
+	checker.Register("redis", down, true)
+	router := gin.New()
+	checker.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, StatusNotReady, report.Status)
+	assert.Equal(t, StatusDown, report.Components["redis"].Status)
+	assert.Equal(t, "connection refused", report.Components["redis"].Error)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
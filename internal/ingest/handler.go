@@ -1,12 +1,26 @@
 package ingest
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/reco-engine/internal/models"
 )
 
+const (
+	// maxBatchEvents caps the number of events accepted by POST /events/batch
+	maxBatchEvents = 1000
+	// maxBatchBytes caps the size of a POST /events/batch body
+	maxBatchBytes = 4 << 20
+)
+
 // Handler handles HTTP requests for event ingestion
 type Handler struct {
 	service *Service
@@ -17,7 +31,8 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
-// HandleIngestEvent handles POST /events
+// HandleIngestEvent handles POST /events. An Idempotency-Key header is used
+// as the event ID when the body does not carry one.
 func (h *Handler) HandleIngestEvent(c *gin.Context) {
 	var event models.Event
 	if err := c.ShouldBindJSON(&event); err != nil {
@@ -25,7 +40,15 @@ func (h *Handler) HandleIngestEvent(c *gin.Context) {
 		return
 	}
 
+	if event.EventID == "" {
+		event.EventID = c.GetHeader("Idempotency-Key")
+	}
+
 	if err := h.service.IngestEvent(c.Request.Context(), &event); err != nil {
+		if errors.Is(err, ErrDuplicateEvent) {
+			c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -33,7 +56,109 @@ func (h *Handler) HandleIngestEvent(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// HandleIngestBatch handles POST /events/batch. The body is either a JSON
+// array of events or newline-delimited JSON (Content-Type: application/x-ndjson).
+// Elements that do not decode as an event are rejected individually.
+func (h *Handler) HandleIngestBatch(c *gin.Context) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchBytes)
+
+	var (
+		elements []json.RawMessage
+		err      error
+	)
+	if strings.HasPrefix(c.ContentType(), "application/x-ndjson") {
+		elements, err = decodeNDJSON(body)
+	} else {
+		elements, err = decodeJSONArray(body)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("body exceeds %d bytes", maxBatchBytes)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(elements) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "batch is empty"})
+		return
+	}
+
+	response, err := h.service.IngestRawEvents(c.Request.Context(), elements)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // HandleHealth handles GET /health
 func (h *Handler) HandleHealth(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 }
+
+// errBatchTooLong is returned once a body holds more than maxBatchEvents
+// elements; decoding stops there
+var errBatchTooLong = fmt.Errorf("batch exceeds %d events", maxBatchEvents)
+
+// decodeJSONArray splits a JSON array into its elements without decoding them
+func decodeJSONArray(r io.Reader) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, invalidBody(err)
+	}
+
+	var elements []json.RawMessage
+	for dec.More() {
+		if len(elements) == maxBatchEvents {
+			return nil, errBatchTooLong
+		}
+		var element json.RawMessage
+		if err := dec.Decode(&element); err != nil {
+			return nil, invalidBody(err)
+		}
+		elements = append(elements, element)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, invalidBody(err)
+	}
+
+	return elements, nil
+}
+
+// decodeNDJSON splits newline-delimited JSON into its non-blank lines without
+// decoding them
+func decodeNDJSON(r io.Reader) ([]json.RawMessage, error) {
+	var elements []json.RawMessage
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if len(elements) == maxBatchEvents {
+			return nil, errBatchTooLong
+		}
+		elements = append(elements, json.RawMessage(bytes.Clone(data)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, invalidBody(err)
+	}
+
+	return elements, nil
+}
+
+// invalidBody keeps a body size error for the caller and replaces any other
+// decode error with a generic one
+func invalidBody(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+	return fmt.Errorf("invalid request body")
+}
@@ -0,0 +1,123 @@
+package ingest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/stream"
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+func TestHandleIngestBatch_EmptyBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &Service{cfg: &config.Config{}}
+	handler := NewHandler(svc)
+
+	req, _ := http.NewRequest("POST", "/events/batch", strings.NewReader("[]"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router := gin.New()
+	router.POST("/events/batch", handler.HandleIngestBatch)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDecodeNDJSON(t *testing.T) {
+	body := `{"user_id":1,"item_id":10,"event_type":"VIEW"}
+
+not-json
+`
+	elements, err := decodeNDJSON(strings.NewReader(body))
+	assert.NoError(t, err)
+	require.Len(t, elements, 2)
+	assert.Equal(t, "not-json", string(elements[1]))
+
+	_, err = decodeNDJSON(strings.NewReader(strings.Repeat("{}\n", maxBatchEvents+1)))
+	assert.ErrorIs(t, err, errBatchTooLong)
+}
+
+func TestDecodeJSONArray(t *testing.T) {
+	elements, err := decodeJSONArray(strings.NewReader(`[{"user_id":1}, "x", 5]`))
+	assert.NoError(t, err)
+	assert.Len(t, elements, 3)
+
+	_, err = decodeJSONArray(strings.NewReader(`{"user_id":1}`))
+	assert.EqualError(t, err, "invalid request body")
+
+	_, err = decodeJSONArray(strings.NewReader("[" + strings.Repeat("{},", maxBatchEvents) + "{}]"))
+	assert.ErrorIs(t, err, errBatchTooLong)
+}
+
+func TestHandleIngestBatch_RejectsUndecodableElements(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	topic := stream.NewMemoryTopic("events")
+	handler := NewHandler(newTestService(t, topic))
+	router := gin.New()
+	router.POST("/events/batch", handler.HandleIngestBatch)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"json array", "application/json", `[{"user_id":1,"item_id":10,"event_type":"VIEW"},{"user_id":"one"},{"user_id":2,"item_id":20,"event_type":"CLICK"}]`},
+		{"ndjson", "application/x-ndjson", "{\"user_id\":1,\"item_id\":10,\"event_type\":\"VIEW\"}\n{\"user_id\":\n{\"user_id\":2,\"item_id\":20,\"event_type\":\"CLICK\"}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/events/batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response models.BatchIngestResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, 2, response.Accepted)
+			assert.Equal(t, 1, response.Rejected)
+			require.Len(t, response.Results, 3)
+			assert.True(t, response.Results[0].Accepted)
+			assert.False(t, response.Results[1].Accepted)
+			assert.Contains(t, response.Results[1].Error, "invalid event: ")
+			assert.True(t, response.Results[2].Accepted)
+		})
+	}
+}
+
+func TestHandleIngestBatch_RejectsOversizedBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewHandler(newTestService(t, stream.NewMemoryTopic("events")))
+	router := gin.New()
+	router.POST("/events/batch", handler.HandleIngestBatch)
+
+	event := `{"user_id":1,"item_id":10,"event_type":"VIEW"}`
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"too many events", "[" + strings.Repeat(event+",", maxBatchEvents) + event + "]", http.StatusBadRequest},
+		{"too many bytes", `[{"user_id":1,"session_id":"` + strings.Repeat("x", maxBatchBytes) + `"}]`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/events/batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
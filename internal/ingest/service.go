@@ -3,6 +3,7 @@ package ingest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -10,22 +11,33 @@ import (
 	"github.com/segmentio/kafka-go"
 	"github.com/yourusername/reco-engine/internal/models"
 	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/stream"
 	"github.com/yourusername/reco-engine/internal/util/config"
 	"github.com/yourusername/reco-engine/internal/util/logger"
 	"github.com/yourusername/reco-engine/internal/util/metrics"
+	"github.com/yourusername/reco-engine/internal/util/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// dedupScope is the Redis dedup namespace for event IDs accepted by ingest
+const dedupScope = "ingest"
+
+// ErrDuplicateEvent is returned when an event ID was already ingested within the dedup window
+var ErrDuplicateEvent = errors.New("duplicate event")
+
 // Service handles event ingestion
 type Service struct {
-	kafkaWriter *kafka.Writer
-	pgStore     *store.PostgresStore
-	cfg         *config.Config
+	publisher  stream.EventPublisher
+	pgStore    store.CatalogStore
+	redisStore store.RecoSignalStore
+	cfg        *config.Config
 }
 
-// NewService creates a new ingest service
-func NewService(cfg *config.Config, pgStore *store.PostgresStore) *Service {
-	writer := kafka.NewWriter(kafka.WriterConfig{
+// NewKafkaWriter creates the async Kafka writer for the events topic
+func NewKafkaWriter(cfg *config.Config) *kafka.Writer {
+	return kafka.NewWriter(kafka.WriterConfig{
 		Brokers:      cfg.Kafka.Brokers,
 		Topic:        cfg.Kafka.Topics.Events,
 		Balancer:     &kafka.LeastBytes{},
@@ -34,24 +46,166 @@ func NewService(cfg *config.Config, pgStore *store.PostgresStore) *Service {
 		Async:        true,
 		RequiredAcks: int(kafka.RequireOne),
 	})
+}
 
+// NewService creates a new ingest service publishing to the given publisher
+func NewService(cfg *config.Config, publisher stream.EventPublisher, pgStore store.CatalogStore, redisStore store.RecoSignalStore) *Service {
 	return &Service{
-		kafkaWriter: writer,
-		pgStore:     pgStore,
-		cfg:         cfg,
+		publisher:  publisher,
+		pgStore:    pgStore,
+		redisStore: redisStore,
+		cfg:        cfg,
 	}
 }
 
 // Close closes the service
 func (s *Service) Close() error {
-	return s.kafkaWriter.Close()
+	return s.publisher.Close()
 }
 
 // IngestEvent ingests an event and publishes to Kafka
 func (s *Service) IngestEvent(ctx context.Context, event *models.Event) error {
+	msg, err := s.prepareEvent(event)
+	if err != nil {
+		return err
+	}
+
+	if !s.claimEvent(ctx, event) {
+		return ErrDuplicateEvent
+	}
+
+	if err := s.publish(ctx, msg); err != nil {
+		s.releaseEvents(event)
+		return err
+	}
+
+	s.recordIngested(event)
+
+	// Optionally store in PostgreSQL (async)
+	go s.storeEvents(tracing.Detach(ctx), []*models.Event{event})
+
+	return nil
+}
+
+// IngestEvents validates each event of a batch and publishes the valid ones
+// to Kafka in a single write. Invalid events are rejected individually
+// instead of failing the whole batch.
+func (s *Service) IngestEvents(ctx context.Context, events []models.Event) (*models.BatchIngestResponse, error) {
+	return s.ingestBatch(ctx, events, nil)
+}
+
+// IngestRawEvents decodes each element of a batch on its own and ingests the
+// ones that decode like IngestEvents. Elements that are not a valid event are
+// rejected individually with their decode error.
+func (s *Service) IngestRawEvents(ctx context.Context, elements []json.RawMessage) (*models.BatchIngestResponse, error) {
+	events := make([]models.Event, len(elements))
+	decodeErrs := make([]error, len(elements))
+	for i, element := range elements {
+		decodeErrs[i] = json.Unmarshal(element, &events[i])
+	}
+	return s.ingestBatch(ctx, events, decodeErrs)
+}
+
+// ingestBatch ingests events, rejecting those with a non-nil error at the same
+// index of decodeErrs, which may be nil
+func (s *Service) ingestBatch(ctx context.Context, events []models.Event, decodeErrs []error) (*models.BatchIngestResponse, error) {
+	response := &models.BatchIngestResponse{
+		Results: make([]models.BatchEventResult, len(events)),
+	}
+
+	valid := make([]int, 0, len(events))
+	prepared := make([]kafka.Message, len(events))
+	for i := range events {
+		response.Results[i].Index = i
+
+		if decodeErrs != nil && decodeErrs[i] != nil {
+			response.Results[i].Error = fmt.Sprintf("invalid event: %v", decodeErrs[i])
+			response.Rejected++
+			continue
+		}
+
+		msg, err := s.prepareEvent(&events[i])
+		if err != nil {
+			response.Results[i].Error = err.Error()
+			response.Rejected++
+			continue
+		}
+		prepared[i] = msg
+		valid = append(valid, i)
+	}
+
+	validEvents := make([]*models.Event, len(valid))
+	for j, i := range valid {
+		validEvents[j] = &events[i]
+	}
+	isNew := s.claimEvents(ctx, validEvents)
+
+	msgs := make([]kafka.Message, 0, len(valid))
+	accepted := make([]*models.Event, 0, len(valid))
+	for j, i := range valid {
+		if !isNew[j] {
+			response.Results[i].Accepted = true
+			response.Results[i].Duplicate = true
+			response.Accepted++
+			continue
+		}
+
+		msgs = append(msgs, prepared[i])
+		accepted = append(accepted, &events[i])
+	}
+
+	if len(msgs) == 0 {
+		return response, nil
+	}
+
+	if err := s.publish(ctx, msgs...); err != nil {
+		s.releaseEvents(accepted...)
+		return nil, err
+	}
+
+	for i := range response.Results {
+		if response.Results[i].Error == "" && !response.Results[i].Duplicate {
+			response.Results[i].Accepted = true
+			response.Accepted++
+		}
+	}
+	for _, event := range accepted {
+		s.recordIngested(event)
+	}
+
+	// Optionally store in PostgreSQL (async)
+	go s.storeEvents(tracing.Detach(ctx), accepted)
+
+	return response, nil
+}
+
+// publish writes messages to the events topic under a producer span whose
+// trace context is carried in each message's headers
+func (s *Service) publish(ctx context.Context, msgs ...kafka.Message) error {
+	topic := s.cfg.Kafka.Topics.Events
+	ctx, span := tracing.Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(tracing.KafkaAttributes(topic)...),
+		trace.WithAttributes(semconv.MessagingBatchMessageCount(len(msgs))))
+	defer span.End()
+
+	for i := range msgs {
+		tracing.InjectKafka(ctx, &msgs[i])
+	}
+
+	if err := s.publisher.WriteMessages(ctx, msgs...); err != nil {
+		tracing.RecordError(span, err)
+		metrics.KafkaPublishErrors.WithLabelValues(topic).Add(float64(len(msgs)))
+		return fmt.Errorf("failed to publish to Kafka: %w", err)
+	}
+	return nil
+}
+
+// prepareEvent validates the event, fills in defaults and builds its Kafka message
+func (s *Service) prepareEvent(event *models.Event) (kafka.Message, error) {
 	// Validate event
 	if err := s.validateEvent(event); err != nil {
-		return fmt.Errorf("invalid event: %w", err)
+		return kafka.Message{}, fmt.Errorf("invalid event: %w", err)
 	}
 
 	// Set timestamp if not provided
@@ -62,41 +216,110 @@ func (s *Service) IngestEvent(ctx context.Context, event *models.Event) error {
 	// Serialize event
 	eventBytes, err := json.Marshal(event)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return kafka.Message{}, fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	// Publish to Kafka
-	msg := kafka.Message{
+	return kafka.Message{
 		Key:   []byte(strconv.FormatInt(event.UserID, 10)),
 		Value: eventBytes,
 		Time:  event.Timestamp,
+	}, nil
+}
+
+// claimEvent records the event ID in the dedup window and reports whether the
+// event is new. Events without an ID are always new. Redis failures fail open
+// so that ingestion keeps working without deduplication.
+func (s *Service) claimEvent(ctx context.Context, event *models.Event) bool {
+	if event.EventID == "" {
+		return true
 	}
 
-	if err := s.kafkaWriter.WriteMessages(ctx, msg); err != nil {
-		metrics.KafkaPublishErrors.WithLabelValues(s.cfg.Kafka.Topics.Events).Inc()
-		return fmt.Errorf("failed to publish to Kafka: %w", err)
+	isNew, err := s.redisStore.MarkEventSeen(ctx, dedupScope, event.EventID, s.cfg.Processing.DedupTTL())
+	if err != nil {
+		logger.Warn("Failed to check event dedup", zap.Error(err), zap.String("event_id", event.EventID))
+		return true
+	}
+	if !isNew {
+		metrics.EventsDeduplicated.WithLabelValues("ingest").Inc()
+		logger.Debug("Duplicate event dropped", zap.String("event_id", event.EventID))
+	}
+	return isNew
+}
+
+// claimEvents records the event IDs of a batch in the dedup window in one
+// round trip and reports for each event whether it is new, like claimEvent
+func (s *Service) claimEvents(ctx context.Context, events []*models.Event) []bool {
+	isNew := make([]bool, len(events))
+	var (
+		ids     []string
+		indexes []int
+	)
+	for i, event := range events {
+		if event.EventID == "" {
+			isNew[i] = true
+			continue
+		}
+		ids = append(ids, event.EventID)
+		indexes = append(indexes, i)
+	}
+	if len(ids) == 0 {
+		return isNew
 	}
 
-	// Update metrics
+	claimed, err := s.redisStore.MarkEventsSeen(ctx, dedupScope, ids, s.cfg.Processing.DedupTTL())
+	if err != nil {
+		logger.Warn("Failed to check event dedup", zap.Error(err), zap.Int("events", len(ids)))
+		for _, i := range indexes {
+			isNew[i] = true
+		}
+		return isNew
+	}
+	for j, i := range indexes {
+		isNew[i] = claimed[j]
+		if !claimed[j] {
+			metrics.EventsDeduplicated.WithLabelValues("ingest").Inc()
+			logger.Debug("Duplicate event dropped", zap.String("event_id", ids[j]))
+		}
+	}
+	return isNew
+}
+
+// releaseEvents forgets claimed event IDs so that a client retry after a
+// failed publish is not treated as a duplicate
+func (s *Service) releaseEvents(events ...*models.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for _, event := range events {
+		if event.EventID == "" {
+			continue
+		}
+		if err := s.redisStore.ForgetEvent(ctx, dedupScope, event.EventID); err != nil {
+			logger.Warn("Failed to release event dedup key", zap.Error(err), zap.String("event_id", event.EventID))
+		}
+	}
+}
+
+func (s *Service) recordIngested(event *models.Event) {
 	metrics.EventsIngested.WithLabelValues(event.EventType).Inc()
 	metrics.KafkaMessagesPublished.WithLabelValues(s.cfg.Kafka.Topics.Events).Inc()
 
 	logger.Debug("Event ingested",
+		zap.String("event_id", event.EventID),
 		zap.Int64("user_id", event.UserID),
 		zap.Int64("item_id", event.ItemID),
 		zap.String("event_type", event.EventType),
 		zap.String("session_id", event.SessionID))
+}
 
-	// Optionally store in PostgreSQL (async)
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.pgStore.InsertEvent(ctx, event); err != nil {
-			logger.Error("Failed to store event in PostgreSQL", zap.Error(err))
-		}
-	}()
+// storeEvents writes events to PostgreSQL in a single batch
+func (s *Service) storeEvents(ctx context.Context, events []*models.Event) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-	return nil
+	if err := s.pgStore.InsertEvents(ctx, events); err != nil {
+		logger.Error("Failed to store events in PostgreSQL", zap.Error(err), zap.Int("events", len(events)))
+	}
 }
 
 func (s *Service) validateEvent(event *models.Event) error {
@@ -109,6 +332,9 @@ func (s *Service) validateEvent(event *models.Event) error {
 	if event.EventType == "" {
 		return fmt.Errorf("event_type is required")
 	}
+	if len(event.EventID) > 128 {
+		return fmt.Errorf("event_id must be at most 128 characters")
+	}
 
 	// Validate event type
 	validTypes := map[string]bool{
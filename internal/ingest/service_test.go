@@ -0,0 +1,121 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/stream"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+)
+
+func newTestService(t *testing.T, publisher stream.EventPublisher) *Service {
+	logger.Init("error", "console")
+	return NewService(&config.Config{}, publisher, store.NewMemoryCatalogStore(), store.NewMemorySignalStore())
+}
+
+func TestIngestEvent_DropsRepeatedEventID(t *testing.T) {
+	ctx := context.Background()
+	topic := stream.NewMemoryTopic("events")
+	svc := newTestService(t, topic)
+
+	require.NoError(t, svc.IngestEvent(ctx, &models.Event{EventID: "a", UserID: 1, ItemID: 10, EventType: models.EventTypeView}))
+	err := svc.IngestEvent(ctx, &models.Event{EventID: "a", UserID: 1, ItemID: 10, EventType: models.EventTypeView})
+	assert.ErrorIs(t, err, ErrDuplicateEvent)
+
+	// Events without an ID are never duplicates
+	require.NoError(t, svc.IngestEvent(ctx, &models.Event{UserID: 1, ItemID: 10, EventType: models.EventTypeView}))
+	require.NoError(t, svc.IngestEvent(ctx, &models.Event{UserID: 1, ItemID: 10, EventType: models.EventTypeView}))
+
+	assert.Len(t, topic.Messages(), 3)
+}
+
+func TestIngestEvents_FlagsRepeatedEventIDs(t *testing.T) {
+	ctx := context.Background()
+	topic := stream.NewMemoryTopic("events")
+	svc := newTestService(t, topic)
+	require.NoError(t, svc.IngestEvent(ctx, &models.Event{EventID: "a", UserID: 1, ItemID: 10, EventType: models.EventTypeView}))
+
+	response, err := svc.IngestEvents(ctx, []models.Event{
+		{EventID: "a", UserID: 1, ItemID: 10, EventType: models.EventTypeView},
+		{EventID: "b", UserID: 1, ItemID: 11, EventType: models.EventTypeView},
+		{EventID: "b", UserID: 1, ItemID: 11, EventType: models.EventTypeView},
+		{EventID: "c", UserID: 1, ItemID: 12, EventType: models.EventTypeView},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, response.Accepted)
+	assert.Equal(t, []models.BatchEventResult{
+		{Index: 0, Accepted: true, Duplicate: true},
+		{Index: 1, Accepted: true},
+		{Index: 2, Accepted: true, Duplicate: true},
+		{Index: 3, Accepted: true},
+	}, response.Results)
+	assert.Len(t, topic.Messages(), 3, "one message for a, b and c each")
+}
+
+// failingPublisher fails every write until it is fixed
+type failingPublisher struct {
+	*stream.MemoryTopic
+	failing bool
+}
+
+func (f *failingPublisher) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if f.failing {
+		return errors.New("broker unavailable")
+	}
+	return f.MemoryTopic.WriteMessages(ctx, msgs...)
+}
+
+func TestIngestEvent_RetryAfterFailedPublishIsNotDuplicate(t *testing.T) {
+	ctx := context.Background()
+	publisher := &failingPublisher{MemoryTopic: stream.NewMemoryTopic("events"), failing: true}
+	svc := newTestService(t, publisher)
+
+	event := models.Event{EventID: "a", UserID: 1, ItemID: 10, EventType: models.EventTypeView}
+	retry := event
+	require.Error(t, svc.IngestEvent(ctx, &event))
+
+	publisher.failing = false
+	require.NoError(t, svc.IngestEvent(ctx, &retry))
+	assert.Len(t, publisher.Messages(), 1)
+}
+
+func TestIngestEvents_ClaimsEventIDsInOneRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	logger.Init("error", "console")
+	mr := miniredis.RunT(t)
+	redisStore, err := store.NewRedisStore(config.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisStore.Close() })
+
+	topic := stream.NewMemoryTopic("events")
+	svc := NewService(&config.Config{}, topic, store.NewMemoryCatalogStore(), redisStore)
+	require.NoError(t, svc.IngestEvent(ctx, &models.Event{EventID: "a", UserID: 1, ItemID: 10, EventType: models.EventTypeView}))
+
+	claims := metrics.RedisOperations.WithLabelValues("MarkEventsSeen", "ok")
+	before := testutil.ToFloat64(claims)
+	response, err := svc.IngestEvents(ctx, []models.Event{
+		{EventID: "a", UserID: 1, ItemID: 10, EventType: models.EventTypeView},
+		{EventID: "b", UserID: 1, ItemID: 11, EventType: models.EventTypeView},
+		{EventID: "b", UserID: 1, ItemID: 11, EventType: models.EventTypeView},
+		{UserID: 1, ItemID: 12, EventType: models.EventTypeView},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(claims))
+	assert.Equal(t, []models.BatchEventResult{
+		{Index: 0, Accepted: true, Duplicate: true},
+		{Index: 1, Accepted: true},
+		{Index: 2, Accepted: true, Duplicate: true},
+		{Index: 3, Accepted: true},
+	}, response.Results)
+	assert.Len(t, topic.Messages(), 3, "a once, then b and the event without an ID")
+}
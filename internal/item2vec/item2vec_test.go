@@ -0,0 +1,107 @@
+package item2vec
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+)
+
+func TestSequenceBuilder_SplitsSessions(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	event := func(userID, itemID int64, session string, minutes int) *models.Event {
+		return &models.Event{
+			UserID:    userID,
+			ItemID:    itemID,
+			SessionID: session,
+			Timestamp: start.Add(time.Duration(minutes) * time.Minute),
+		}
+	}
+
+	b := NewSequenceBuilder(30 * time.Minute)
+	for _, e := range []*models.Event{
+		event(1, 10, "a", 0),
+		event(2, 20, "", 0),
+		event(1, 11, "a", 1),
+		event(1, 11, "a", 2), // repeated item
+		event(2, 21, "", 5),
+		event(1, 12, "b", 3), // other session of the same user
+		event(1, 13, "b", 4),
+		event(2, 22, "", 60), // after the gap
+		event(1, 14, "a", 90),
+		event(1, 0, "a", 91),
+	} {
+		b.Add(e)
+	}
+
+	assert.ElementsMatch(t, [][]int64{
+		{10, 11},
+		{20, 21},
+		{12, 13},
+	}, b.Sequences())
+}
+
+func TestTrain_SimilarItemsAreClose(t *testing.T) {
+	// Two groups of items that never share a session
+	rng := rand.New(rand.NewSource(7))
+	groups := [][]int64{{1, 2, 3, 4, 5}, {11, 12, 13, 14, 15}}
+	var sequences [][]int64
+	for i := 0; i < 400; i++ {
+		group := groups[i%2]
+		seq := make([]int64, 6)
+		for j := range seq {
+			seq[j] = group[rng.Intn(len(group))]
+		}
+		sequences = append(sequences, seq)
+	}
+	sequences = append(sequences, []int64{99, 1}) // below MinCount
+
+	cfg := DefaultConfig()
+	cfg.Dim = 16
+	cfg.Epochs = 10
+	cfg.Sample = 0
+	model, err := Train(sequences, cfg)
+	require.NoError(t, err)
+
+	require.Len(t, model.Embeddings, 10)
+	assert.NotContains(t, model.Embeddings, int64(99))
+	assert.Len(t, model.Embeddings[1], 16)
+	assert.Equal(t, 400, model.Sequences)
+	assert.Greater(t, model.Pairs, int64(0))
+	assert.Less(t, model.Loss, 3.0)
+
+	// Every item is closer to the items of its group than to the others
+	for _, a := range groups[0] {
+		same, other := 1.0, -1.0
+		for _, b := range groups[0] {
+			if a != b {
+				same = math.Min(same, cosine(model.Embeddings[a], model.Embeddings[b]))
+			}
+		}
+		for _, b := range groups[1] {
+			other = math.Max(other, cosine(model.Embeddings[a], model.Embeddings[b]))
+		}
+		assert.Greater(t, same, other+0.3, "item %d", a)
+	}
+
+	again, err := Train(sequences, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, model.Embeddings, again.Embeddings, "training is deterministic")
+
+	_, err = Train([][]int64{{1, 2}}, cfg)
+	assert.ErrorIs(t, err, ErrNoSequences)
+}
+
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	return dot / math.Sqrt(na*nb)
+}
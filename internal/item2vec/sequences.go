@@ -0,0 +1,83 @@
+package item2vec
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yourusername/reco-engine/internal/models"
+)
+
+// SequenceBuilder groups logged events into the item sequences of browsing
+// sessions. Events with a session ID are grouped by session, the others by
+// user, and a sequence is cut wherever two events are further apart than the
+// gap. Repeated events on the same item (a view, then a cart) count once.
+type SequenceBuilder struct {
+	gap  time.Duration
+	open map[string]*sequence
+	done [][]int64
+}
+
+type sequence struct {
+	items  []int64
+	lastAt time.Time
+}
+
+// NewSequenceBuilder creates a builder that cuts sequences at the given gap,
+// or only at session boundaries if gap is zero
+func NewSequenceBuilder(gap time.Duration) *SequenceBuilder {
+	return &SequenceBuilder{
+		gap:  gap,
+		open: make(map[string]*sequence),
+	}
+}
+
+// Add appends an event to its sequence. Events must be added oldest first.
+func (b *SequenceBuilder) Add(event *models.Event) {
+	if event.ItemID == 0 {
+		return
+	}
+
+	key := "s:" + event.SessionID
+	if event.SessionID == "" {
+		key = fmt.Sprintf("u:%d", event.UserID)
+	}
+
+	seq, ok := b.open[key]
+	if ok && b.gap > 0 && event.Timestamp.Sub(seq.lastAt) > b.gap {
+		b.close(seq)
+		ok = false
+	}
+	if !ok {
+		seq = &sequence{}
+		b.open[key] = seq
+	}
+
+	seq.lastAt = event.Timestamp
+	if n := len(seq.items); n > 0 && seq.items[n-1] == event.ItemID {
+		return
+	}
+	seq.items = append(seq.items, event.ItemID)
+}
+
+// Sequences closes the open sequences and returns all sequences of at least
+// two items. Sequences of one item carry no co-occurrence to learn from.
+func (b *SequenceBuilder) Sequences() [][]int64 {
+	keys := make([]string, 0, len(b.open))
+	for key := range b.open {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		b.close(b.open[key])
+		delete(b.open, key)
+	}
+	return b.done
+}
+
+func (b *SequenceBuilder) close(seq *sequence) {
+	if len(seq.items) >= 2 {
+		b.done = append(b.done, seq.items)
+	}
+}
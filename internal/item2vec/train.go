@@ -0,0 +1,280 @@
+// Package item2vec learns item embeddings from browsing sessions with
+// skip-gram and negative sampling (Barkan & Koenigstein, 2016): items that
+// appear near each other in sessions get similar vectors.
+package item2vec
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ErrNoSequences is returned when no sequence has two items that occur often
+// enough to train on
+var ErrNoSequences = errors.New("no trainable sequences")
+
+// Config holds the training parameters
+type Config struct {
+	Dim          int     // embedding dimension
+	Window       int     // maximum distance between an item and its context
+	Epochs       int     // passes over the sequences
+	Negative     int     // negative samples per positive pair
+	MinCount     int     // items seen fewer times are dropped
+	LearningRate float64 // starting rate, decayed linearly to nearly zero
+	Sample       float64 // subsampling threshold for frequent items, 0 disables it
+	Seed         int64
+}
+
+// DefaultConfig returns parameters that work well for session data
+func DefaultConfig() Config {
+	return Config{
+		Dim:          64,
+		Window:       5,
+		Epochs:       5,
+		Negative:     5,
+		MinCount:     2,
+		LearningRate: 0.025,
+		Sample:       1e-3,
+		Seed:         1,
+	}
+}
+
+// Model holds trained embeddings and training statistics
+type Model struct {
+	Embeddings map[int64][]float64
+	Sequences  int     // sequences trained on
+	Tokens     int64   // item occurrences in those sequences
+	Pairs      int64   // positive pairs in the last epoch
+	Loss       float64 // mean loss per pair in the last epoch
+}
+
+// Train learns embeddings for the items of the given sequences. Training is
+// single threaded, so the same sequences and seed give the same model.
+func Train(sequences [][]int64, cfg Config) (*Model, error) {
+	defaults := DefaultConfig()
+	if cfg.Dim < 1 {
+		cfg.Dim = defaults.Dim
+	}
+	if cfg.Window < 1 {
+		cfg.Window = defaults.Window
+	}
+	if cfg.Epochs < 1 {
+		cfg.Epochs = defaults.Epochs
+	}
+	if cfg.Negative < 1 {
+		cfg.Negative = defaults.Negative
+	}
+	if cfg.LearningRate <= 0 {
+		cfg.LearningRate = defaults.LearningRate
+	}
+
+	v := newVocab(sequences, cfg.MinCount)
+	corpus, tokens := v.encode(sequences)
+	if len(corpus) == 0 {
+		return nil, ErrNoSequences
+	}
+
+	t := &trainer{
+		cfg:    cfg,
+		vocab:  v,
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+		input:  make([]float32, len(v.ids)*cfg.Dim),
+		output: make([]float32, len(v.ids)*cfg.Dim),
+		grad:   make([]float32, cfg.Dim),
+		total:  float64(cfg.Epochs) * float64(tokens),
+	}
+	for i := range t.input {
+		t.input[i] = (t.rng.Float32() - 0.5) / float32(cfg.Dim)
+	}
+	t.keep = v.keepProbabilities(cfg.Sample, tokens)
+
+	model := &Model{Sequences: len(corpus), Tokens: tokens}
+	for epoch := 0; epoch < cfg.Epochs; epoch++ {
+		t.pairs, t.loss = 0, 0
+		for _, i := range t.rng.Perm(len(corpus)) {
+			t.trainSequence(corpus[i])
+		}
+	}
+	model.Pairs = t.pairs
+	if t.pairs > 0 {
+		model.Loss = t.loss / float64(t.pairs)
+	}
+
+	model.Embeddings = make(map[int64][]float64, len(v.ids))
+	for w, id := range v.ids {
+		vec := make([]float64, cfg.Dim)
+		for d, x := range t.input[w*cfg.Dim : (w+1)*cfg.Dim] {
+			vec[d] = float64(x)
+		}
+		model.Embeddings[id] = vec
+	}
+	return model, nil
+}
+
+type trainer struct {
+	cfg   Config
+	vocab *vocab
+	rng   *rand.Rand
+	keep  []float64
+
+	input  []float32 // item vectors, the embeddings
+	output []float32 // context vectors
+	grad   []float32
+
+	total     float64 // item occurrences over all epochs, for the rate decay
+	processed float64
+	pairs     int64
+	loss      float64
+}
+
+func (t *trainer) trainSequence(seq []int32) {
+	t.processed += float64(len(seq))
+	rate := t.cfg.LearningRate * math.Max(1e-4, 1-t.processed/(t.total+1))
+
+	// Subsample frequent items so that they do not dominate the pairs
+	kept := make([]int32, 0, len(seq))
+	for _, w := range seq {
+		if t.keep == nil || t.rng.Float64() < t.keep[w] {
+			kept = append(kept, w)
+		}
+	}
+
+	for i, w := range kept {
+		// A random window per position weights near contexts more
+		window := 1 + t.rng.Intn(t.cfg.Window)
+		for j := max(0, i-window); j <= min(len(kept)-1, i+window); j++ {
+			if j != i && kept[j] != w {
+				t.trainPair(w, kept[j], float32(rate))
+			}
+		}
+	}
+}
+
+// trainPair takes one gradient step on the pair (w, context) against
+// negative samples drawn from the smoothed item frequencies
+func (t *trainer) trainPair(w, context int32, rate float32) {
+	dim := t.cfg.Dim
+	vec := t.input[int(w)*dim : int(w+1)*dim]
+	for d := range t.grad {
+		t.grad[d] = 0
+	}
+
+	for n := 0; n <= t.cfg.Negative; n++ {
+		target, label := context, float32(1)
+		if n > 0 {
+			target, label = t.vocab.sampleNegative(t.rng), 0
+			if target == context {
+				continue
+			}
+		}
+		out := t.output[int(target)*dim : int(target+1)*dim]
+
+		var dot float32
+		for d := range vec {
+			dot += vec[d] * out[d]
+		}
+		p := sigmoid(dot)
+		if label == 1 {
+			t.loss -= math.Log(math.Max(float64(p), 1e-7))
+		} else {
+			t.loss -= math.Log(math.Max(float64(1-p), 1e-7))
+		}
+
+		g := (label - p) * rate
+		for d := range vec {
+			t.grad[d] += g * out[d]
+			out[d] += g * vec[d]
+		}
+	}
+
+	for d := range vec {
+		vec[d] += t.grad[d]
+	}
+	t.pairs++
+}
+
+func sigmoid(x float32) float32 {
+	if x > 6 {
+		x = 6
+	} else if x < -6 {
+		x = -6
+	}
+	return float32(1 / (1 + math.Exp(-float64(x))))
+}
+
+// vocab maps item IDs to dense indexes, in item order
+type vocab struct {
+	ids    []int64
+	index  map[int64]int32
+	counts []int64
+	cumul  []float64 // cumulative count^0.75, for negative sampling
+}
+
+func newVocab(sequences [][]int64, minCount int) *vocab {
+	counts := make(map[int64]int64)
+	for _, seq := range sequences {
+		for _, id := range seq {
+			counts[id]++
+		}
+	}
+
+	v := &vocab{index: make(map[int64]int32)}
+	for id, n := range counts {
+		if n >= int64(minCount) {
+			v.ids = append(v.ids, id)
+		}
+	}
+	sort.Slice(v.ids, func(i, j int) bool { return v.ids[i] < v.ids[j] })
+
+	v.counts = make([]int64, len(v.ids))
+	v.cumul = make([]float64, len(v.ids))
+	var sum float64
+	for i, id := range v.ids {
+		v.index[id] = int32(i)
+		v.counts[i] = counts[id]
+		sum += math.Pow(float64(counts[id]), 0.75)
+		v.cumul[i] = sum
+	}
+	return v
+}
+
+// encode maps sequences to vocab indexes, dropping unknown items and the
+// sequences left with fewer than two items. It also returns the number of
+// item occurrences kept.
+func (v *vocab) encode(sequences [][]int64) ([][]int32, int64) {
+	var corpus [][]int32
+	var tokens int64
+	for _, seq := range sequences {
+		encoded := make([]int32, 0, len(seq))
+		for _, id := range seq {
+			if w, ok := v.index[id]; ok {
+				encoded = append(encoded, w)
+			}
+		}
+		if len(encoded) >= 2 {
+			corpus = append(corpus, encoded)
+			tokens += int64(len(encoded))
+		}
+	}
+	return corpus, tokens
+}
+
+// keepProbabilities returns the chance of keeping each item occurrence, as in
+// word2vec, or nil when subsampling is disabled
+func (v *vocab) keepProbabilities(sample float64, tokens int64) []float64 {
+	if sample <= 0 {
+		return nil
+	}
+	keep := make([]float64, len(v.counts))
+	for i, n := range v.counts {
+		f := float64(n) / float64(tokens)
+		keep[i] = math.Min(1, (math.Sqrt(f/sample)+1)*sample/f)
+	}
+	return keep
+}
+
+func (v *vocab) sampleNegative(rng *rand.Rand) int32 {
+	r := rng.Float64() * v.cumul[len(v.cumul)-1]
+	return int32(sort.SearchFloat64s(v.cumul, r))
+}
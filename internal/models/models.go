@@ -5,6 +5,7 @@ import "time"
 // Event represents a user interaction event
 type Event struct {
 	ID        int64                  `json:"id,omitempty" db:"id"`
+	EventID   string                 `json:"event_id,omitempty" db:"event_id"`
 	UserID    int64                  `json:"user_id" db:"user_id"`
 	ItemID    int64                  `json:"item_id" db:"item_id"`
 	EventType string                 `json:"event_type" db:"event_type"`
@@ -48,21 +49,61 @@ type Recommendation struct {
 	ItemID int64   `json:"item_id"`
 	Score  float64 `json:"score"`
 	Reason string  `json:"reason"`
+	Item   *Item   `json:"item,omitempty"`
+}
+
+// ExperimentAssignment identifies the experiment variant that served a response
+type ExperimentAssignment struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
 }
 
 // RecommendationResponse is the API response
 type RecommendationResponse struct {
-	UserID          int64            `json:"user_id"`
-	Recommendations []Recommendation `json:"recommendations"`
+	UserID          int64                  `json:"user_id"`
+	Recommendations []Recommendation       `json:"recommendations"`
+	Experiments     []ExperimentAssignment `json:"experiments,omitempty"`
+}
+
+// BatchEventResult reports the outcome for one event of a batch ingestion
+type BatchEventResult struct {
+	Index     int    `json:"index"`
+	Accepted  bool   `json:"accepted"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BatchIngestResponse is the API response for batch ingestion
+type BatchIngestResponse struct {
+	Accepted int                `json:"accepted"`
+	Rejected int                `json:"rejected"`
+	Results  []BatchEventResult `json:"results"`
 }
 
 // Model represents an offline trained model
 type Model struct {
-	ID        int64                  `json:"id" db:"id"`
-	ModelName string                 `json:"model_name" db:"model_name"`
-	Version   string                 `json:"version" db:"version"`
-	ModelType string                 `json:"model_type" db:"model_type"`
-	Metrics   map[string]interface{} `json:"metrics" db:"metrics"`
-	Config    map[string]interface{} `json:"config" db:"config"`
-	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+	ID          int64                  `json:"id" db:"id"`
+	ModelName   string                 `json:"model_name" db:"model_name"`
+	Version     string                 `json:"version" db:"version"`
+	ModelType   string                 `json:"model_type" db:"model_type"`
+	Metrics     map[string]interface{} `json:"metrics" db:"metrics"`
+	Config      map[string]interface{} `json:"config" db:"config"`
+	CreatedAt   time.Time              `json:"created_at" db:"created_at"`
+	Status      string                 `json:"status" db:"status"`
+	Active      bool                   `json:"active" db:"is_active"`
+	ActivatedAt *time.Time             `json:"activated_at,omitempty" db:"activated_at"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty" db:"expires_at"` // when the version's Redis data expires
+}
+
+// Model status constants. A version stays pending while its trainer writes
+// its data and can only be served once complete.
+const (
+	ModelStatusPending  = "pending"
+	ModelStatusComplete = "complete"
+)
+
+// ModelListResponse is the API response for listing registered models
+type ModelListResponse struct {
+	Models  []Model           `json:"models"`
+	Serving map[string]string `json:"serving"` // version served per model name
 }
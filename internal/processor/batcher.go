@@ -0,0 +1,349 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+	"github.com/yourusername/reco-engine/internal/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// defaultFlushInterval is used when processing.flush_interval is not set
+const defaultFlushInterval = 250 * time.Millisecond
+
+// runBatcher collects decoded events and flushes them to Redis once
+// processing.batch_size events are pending or processing.flush_interval has
+// passed. Events still pending when ctx is cancelled are left uncommitted
+// for redelivery.
+func (s *Service) runBatcher(ctx context.Context, events <-chan *pendingEvent) {
+	size := s.cfg.Processing.BatchSize
+	if size < 1 {
+		size = 1
+	}
+	interval := s.cfg.Processing.FlushInterval
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pending []*pendingEvent
+	drop := func() {
+		for _, p := range pending {
+			p.end(ctx.Err())
+		}
+	}
+
+	for {
+		select {
+		case p, ok := <-events:
+			if !ok {
+				drop()
+				return
+			}
+			pending = append(pending, p)
+			if len(pending) >= size {
+				s.flush(ctx, pending)
+				pending = nil
+			}
+		case <-ticker.C:
+			if len(pending) > 0 {
+				s.flush(ctx, pending)
+				pending = nil
+			}
+		case <-ctx.Done():
+			drop()
+			return
+		}
+	}
+}
+
+// flush applies a batch of events and then commits their offsets. A failed
+// flush is retried with the processing.retry backoff up to
+// processing.retry.max_attempts times. After that the events are applied one
+// at a time with the same policy, and those that still fail are sent to the
+// dead-letter topic, so that one bad event cannot stall its partition. No
+// offset is committed before its event is in Redis or the dead-letter topic.
+func (s *Service) flush(ctx context.Context, events []*pendingEvent) {
+	links := make([]trace.Link, len(events))
+	for i, p := range events {
+		links[i] = trace.Link{SpanContext: p.span.SpanContext()}
+	}
+	ctx, span := tracing.Start(ctx, "flushSignals",
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("batch.size", len(events))))
+	defer span.End()
+
+	metrics.ProcessorBatchSize.Observe(float64(len(events)))
+
+	isNew, attempts, err := s.applyWithRetry(ctx, span, events)
+	failed := make([]error, len(events))
+	tries := make([]int, len(events))
+	if err != nil && len(events) == 1 {
+		failed[0], tries[0] = err, attempts
+	} else if err != nil && ctx.Err() == nil {
+		logger.Error("Failed to flush event batch, applying events one at a time",
+			zap.Error(err),
+			zap.Int("events", len(events)),
+			zap.Int("attempts", attempts))
+		isNew = make([]bool, len(events))
+		for i := range events {
+			var one []bool
+			one, tries[i], failed[i] = s.applyWithRetry(ctx, span, events[i:i+1])
+			if failed[i] == nil {
+				isNew[i] = one[0]
+			}
+		}
+	}
+
+	for i, p := range events {
+		if ctx.Err() != nil {
+			// Shutting down: leave the rest uncommitted for redelivery
+			tracing.RecordError(span, ctx.Err())
+			for _, rest := range events[i:] {
+				rest.end(ctx.Err())
+			}
+			return
+		}
+
+		switch {
+		case failed[i] != nil:
+			if err := s.deadLetter(ctx, p.job.msg, failed[i], tries[i]); err != nil {
+				p.end(err)
+				continue
+			}
+			p.end(failed[i])
+		case isNew[i]:
+			metrics.EventsProcessed.WithLabelValues(p.event.EventType).Inc()
+			logger.Debug("Event processed",
+				zap.Int64("user_id", p.event.UserID),
+				zap.Int64("item_id", p.event.ItemID),
+				zap.String("event_type", p.event.EventType))
+			p.end(nil)
+		default:
+			metrics.EventsDeduplicated.WithLabelValues("processor").Inc()
+			logger.Debug("Duplicate event skipped", zap.String("dedup_id", p.dedupID))
+			p.end(nil)
+		}
+
+		s.commit(ctx, p.job)
+		metrics.KafkaMessagesConsumed.WithLabelValues(s.cfg.Kafka.Topics.Events).Inc()
+	}
+}
+
+// applyWithRetry applies events with the processing.retry policy and returns
+// which were new and how many attempts were made
+func (s *Service) applyWithRetry(ctx context.Context, span trace.Span, events []*pendingEvent) ([]bool, int, error) {
+	maxAttempts := s.cfg.Processing.Retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		isNew, err := s.applyBatch(ctx, events)
+		metrics.ProcessorFlushLatency.Observe(time.Since(start).Seconds())
+		if err == nil {
+			metrics.ProcessorFlushes.WithLabelValues("ok").Inc()
+			return isNew, attempt, nil
+		}
+
+		metrics.ProcessorFlushes.WithLabelValues("error").Inc()
+		metrics.EventProcessingErrors.Inc()
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt)))
+		if ctx.Err() == nil {
+			logger.Warn("Failed to flush event batch",
+				zap.Error(err),
+				zap.Int("events", len(events)),
+				zap.Int("attempt", attempt))
+		}
+		if attempt >= maxAttempts {
+			return nil, attempt, err
+		}
+
+		metrics.EventProcessingRetries.Inc()
+		if sleepErr := sleepContext(ctx, s.cfg.Processing.Retry.Backoff(attempt)); sleepErr != nil {
+			return nil, attempt, err
+		}
+	}
+}
+
+// applyBatch merges the updates of the batch's new events and writes them in
+// one transaction, which also records the events as seen. It reports which
+// events were new. Events already seen, or repeated earlier in the batch,
+// are skipped.
+func (s *Service) applyBatch(ctx context.Context, events []*pendingEvent) ([]bool, error) {
+	dedupIDs := make([]string, len(events))
+	for i, p := range events {
+		dedupIDs[i] = p.dedupID
+	}
+
+	// Each conflict means another consumer applied at least one more of the
+	// events, so the check converges within len(events) rounds
+	for conflicts := 0; ; conflicts++ {
+		seen, err := s.redisStore.EventsSeen(ctx, dedupScope, dedupIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check event dedup: %w", err)
+		}
+
+		isNew := make([]bool, len(events))
+		inBatch := make(map[string]bool, len(events))
+		var fresh []string
+		var userIDs []int64
+		users := make(map[int64]bool)
+		for i, p := range events {
+			if seen[i] || inBatch[p.dedupID] {
+				continue
+			}
+			inBatch[p.dedupID] = true
+			isNew[i] = true
+			fresh = append(fresh, p.dedupID)
+			if !users[p.event.UserID] {
+				users[p.event.UserID] = true
+				userIDs = append(userIDs, p.event.UserID)
+			}
+		}
+		if len(fresh) == 0 {
+			return isNew, nil
+		}
+
+		recent, err := s.redisStore.GetRecentEventsForUsers(ctx, userIDs, s.cfg.Processing.CoviewEvents())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get recent events: %w", err)
+		}
+
+		batch := s.buildSignalBatch(events, isNew, recent)
+		batch.MarkSeen(dedupScope, fresh, s.cfg.Processing.DedupTTL())
+		err = s.redisStore.ApplySignalBatch(ctx, batch)
+		if errors.Is(err, store.ErrEventsSeen) && conflicts < len(events) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return isNew, nil
+	}
+}
+
+// buildSignalBatch merges the updates of the new events into one batch.
+// recent holds each user's recent events as stored before the batch; an
+// event is paired with the events of the same relation that the user had
+// right before it, in the batch or earlier, within processing.coview_window.
+func (s *Service) buildSignalBatch(events []*pendingEvent, isNew []bool, recent map[int64][]store.RecentItem) *store.SignalBatch {
+	cfg := s.cfg.Processing
+	batch := store.NewSignalBatch(cfg.RecentItemsLimit, cfg.PurchaseHistory)
+	touched := make(map[int64][]store.RecentItem) // items touched in this batch per user, oldest first
+
+	for i, p := range events {
+		if !isNew[i] {
+			continue
+		}
+		event := &p.event
+		current := store.RecentItem{ItemID: event.ItemID, EventType: event.EventType, SessionID: event.SessionID, TouchedAt: p.touchedAt}
+
+		batch.AddRecentItem(event.UserID, current)
+		batch.AddPopularity(event.ItemID, p.weight)
+		if p.category != "" {
+			batch.AddCategoryPopularity(p.category, event.ItemID, p.weight)
+		}
+
+		// Remember purchases so that the API can filter them out
+		if event.EventType == models.EventTypePurchase && cfg.PurchaseHistory > 0 {
+			batch.AddPurchase(event.UserID, event.ItemID, p.touchedAt)
+		}
+
+		// The event itself takes the first slot of the window
+		earlier := touched[event.UserID]
+		window := cfg.CoviewEvents()
+		previous := make([]store.RecentItem, 0, window-1)
+		for j := len(earlier) - 1; j >= 0 && len(previous) < window-1; j-- {
+			previous = append(previous, earlier[j])
+		}
+		for _, item := range recent[event.UserID] {
+			if len(previous) >= window-1 {
+				break
+			}
+			previous = append(previous, item)
+		}
+		relation := relationFor(event.EventType)
+		for j, other := range previous {
+			if relation == "" || relationFor(other.EventType) != relation || !withinWindow(cfg, current, other) {
+				continue
+			}
+			weight := cfg.CoviewWeight(j + 1)
+			if relation != store.RelationCoView {
+				weight *= p.weight
+			}
+			addRelated(batch, relation, current.ItemID, other.ItemID, weight)
+		}
+
+		// Directed transition from the user's event right before this one
+		var last *store.RecentItem
+		if len(earlier) > 0 {
+			last = &earlier[len(earlier)-1]
+		} else if len(recent[event.UserID]) > 0 {
+			last = &recent[event.UserID][0]
+		}
+		if last != nil && last.ItemID != current.ItemID && withinWindow(cfg, current, *last) {
+			batch.AddRelated(store.RelationTransition, last.ItemID, current.ItemID, 1)
+		}
+
+		touched[event.UserID] = append(earlier, current)
+	}
+
+	return batch
+}
+
+// withinWindow reports whether two events of a user are close enough to be
+// paired: in the same session when both have one, and no further apart
+// than processing.coview_max_gap
+func withinWindow(cfg config.ProcessingConfig, a, b store.RecentItem) bool {
+	if cfg.CoviewSameSession && a.SessionID != "" && b.SessionID != "" && a.SessionID != b.SessionID {
+		return false
+	}
+	if cfg.CoviewMaxGap > 0 {
+		gap := a.TouchedAt.Sub(b.TouchedAt)
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap > cfg.CoviewMaxGap {
+			return false
+		}
+	}
+	return true
+}
+
+// relationFor returns the item-item relation fed by pairs of events of the
+// given type: browsing events are co-views, carts and purchases are kept
+// apart so that they can be weighted by intent
+func relationFor(eventType string) string {
+	switch eventType {
+	case models.EventTypeView, models.EventTypeClick:
+		return store.RelationCoView
+	case models.EventTypeCart:
+		return store.RelationCoCart
+	case models.EventTypePurchase:
+		return store.RelationCoPurchase
+	default:
+		return ""
+	}
+}
+
+// addRelated adds weight to a relation in both directions, skipping the item itself
+func addRelated(batch *store.SignalBatch, relation string, itemID, otherID int64, weight float64) {
+	if itemID == otherID {
+		return
+	}
+	batch.AddRelated(relation, itemID, otherID, weight)
+	batch.AddRelated(relation, otherID, itemID, weight)
+}
@@ -0,0 +1,339 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/stream"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestBatchService(t *testing.T, redisStore store.RecoSignalStore) (*Service, *stream.MemoryTopic) {
+	logger.Init("error", "console")
+	topic := stream.NewMemoryTopic("events")
+	t.Cleanup(func() { topic.Close() })
+
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{
+			RecentItemsLimit: 50,
+			CoviewWindow:     3,
+			PurchaseHistory:  24 * time.Hour,
+			Retry:            config.RetryConfig{MaxAttempts: 1000, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+		},
+		EventWeights: config.EventWeightsConfig{View: 1, Purchase: 10},
+	}
+	return NewService(cfg, topic, nil, redisStore, store.NewMemoryCatalogStore()), topic
+}
+
+// pendingEvents writes the events to the topic and tracks them as fetched
+func pendingEvents(t *testing.T, s *Service, topic *stream.MemoryTopic, events ...models.Event) []*pendingEvent {
+	ctx := context.Background()
+	pending := make([]*pendingEvent, len(events))
+	for i, event := range events {
+		require.NoError(t, topic.WriteMessages(ctx, kafka.Message{}))
+		msg, err := topic.FetchMessage(ctx)
+		require.NoError(t, err)
+
+		pending[i] = &pendingEvent{
+			job:     s.offsets.track(msg),
+			event:   event,
+			dedupID: eventDedupID(msg, &event),
+			span:    trace.SpanFromContext(ctx),
+		}
+		s.processEvent(ctx, pending[i])
+	}
+	return pending
+}
+
+func TestFlush_MergesBatchAndCommits(t *testing.T) {
+	ctx := context.Background()
+	redisStore := newTestRedisStore(t)
+	s, topic := newTestBatchService(t, redisStore)
+
+	now := time.Now()
+	earlier := store.NewSignalBatch(50, 0)
+	earlier.AddRecentItem(1, store.RecentItem{ItemID: 9, EventType: models.EventTypeView, TouchedAt: now.Add(-time.Hour)})
+	require.NoError(t, redisStore.ApplySignalBatch(ctx, earlier))
+
+	events := pendingEvents(t, s, topic,
+		models.Event{EventID: "a", UserID: 1, ItemID: 1, EventType: models.EventTypeView, Timestamp: now},
+		models.Event{EventID: "b", UserID: 1, ItemID: 2, EventType: models.EventTypeView, Timestamp: now},
+		models.Event{EventID: "a", UserID: 1, ItemID: 1, EventType: models.EventTypeView, Timestamp: now},
+		models.Event{EventID: "c", UserID: 1, ItemID: 3, EventType: models.EventTypeView, Timestamp: now},
+		models.Event{EventID: "e", UserID: 1, ItemID: 4, EventType: models.EventTypePurchase, Timestamp: now},
+		models.Event{EventID: "f", UserID: 1, ItemID: 5, EventType: models.EventTypePurchase, Timestamp: now},
+		models.Event{EventID: "d", UserID: 2, ItemID: 1, EventType: models.EventTypeView, Timestamp: now},
+	)
+	s.flush(ctx, events)
+
+	assert.Equal(t, int64(7), topic.Committed())
+
+	recent, err := redisStore.GetRecentItems(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5", "4", "3", "2", "1", "9"}, recent)
+
+	popular, err := redisStore.GetPopularItems(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []redis.Z{
+		{Score: 10, Member: "5"}, {Score: 10, Member: "4"}, {Score: 2, Member: "1"}, {Score: 1, Member: "3"}, {Score: 1, Member: "2"},
+	}, popular)
+
+	// The duplicate of "a" adds nothing; item 3 is co-viewed with the two
+	// items before it but not with 9, which is outside the window. The
+	// purchases are only related to each other, weighted by PURCHASE.
+	coView, err := redisStore.GetRelatedItems(ctx, store.RelationCoView, 1, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []redis.Z{{Score: 1, Member: "9"}, {Score: 1, Member: "2"}, {Score: 1, Member: "3"}}, coView)
+	coView, err = redisStore.GetRelatedItems(ctx, store.RelationCoView, 3, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []redis.Z{{Score: 1, Member: "2"}, {Score: 1, Member: "1"}}, coView)
+
+	coPurchase, err := redisStore.GetRelatedItems(ctx, store.RelationCoPurchase, 5, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Score: 10, Member: "4"}}, coPurchase)
+	coView, err = redisStore.GetRelatedItems(ctx, store.RelationCoView, 4, 10)
+	require.NoError(t, err)
+	assert.Empty(t, coView)
+
+	purchased, err := redisStore.GetPurchasedItemsSince(ctx, 1, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4", "5"}, purchased)
+}
+
+func TestFlush_CountsCategoryPopularity(t *testing.T) {
+	ctx := context.Background()
+	redisStore := newTestRedisStore(t)
+	s, topic := newTestBatchService(t, redisStore)
+	s.pgStore = store.NewMemoryCatalogStore(
+		models.Item{ID: 1, Category: "books"},
+		models.Item{ID: 2, Category: "electronics"},
+	)
+
+	// Item 3 is not in the catalog
+	s.flush(ctx, pendingEvents(t, s, topic,
+		models.Event{EventID: "a", UserID: 1, ItemID: 1, EventType: models.EventTypeView},
+		models.Event{EventID: "b", UserID: 1, ItemID: 2, EventType: models.EventTypePurchase},
+		models.Event{EventID: "c", UserID: 2, ItemID: 1, EventType: models.EventTypeView},
+		models.Event{EventID: "d", UserID: 2, ItemID: 3, EventType: models.EventTypeView}))
+
+	books, err := redisStore.GetCategoryPopularItems(ctx, "books", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Score: 2, Member: "1"}}, books)
+	electronics, err := redisStore.GetCategoryPopularItems(ctx, "electronics", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Score: 10, Member: "2"}}, electronics)
+	uncategorized, err := redisStore.GetCategoryPopularItems(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, uncategorized)
+
+	// The unknown item still counts globally
+	popular, err := redisStore.GetPopularItems(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Score: 10, Member: "2"}, {Score: 2, Member: "1"}, {Score: 1, Member: "3"}}, popular)
+}
+
+func TestFlush_SkipsRedeliveredEvents(t *testing.T) {
+	ctx := context.Background()
+	redisStore := newTestRedisStore(t)
+	s, topic := newTestBatchService(t, redisStore)
+
+	s.flush(ctx, pendingEvents(t, s, topic,
+		models.Event{EventID: "a", UserID: 1, ItemID: 1, EventType: models.EventTypeView},
+		models.Event{UserID: 1, ItemID: 2, EventType: models.EventTypeView}))
+
+	// "a" comes back under a new offset; an event without an ID at a new
+	// offset is a different event
+	s.flush(ctx, pendingEvents(t, s, topic,
+		models.Event{EventID: "a", UserID: 1, ItemID: 1, EventType: models.EventTypeView},
+		models.Event{UserID: 1, ItemID: 2, EventType: models.EventTypeView}))
+
+	assert.Equal(t, int64(4), topic.Committed())
+	popular, err := redisStore.GetPopularItems(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Score: 2, Member: "2"}, {Score: 1, Member: "1"}}, popular)
+}
+
+func TestFlush_PurchaseWithoutTimestampIsKept(t *testing.T) {
+	ctx := context.Background()
+	redisStore := newTestRedisStore(t)
+	s, topic := newTestBatchService(t, redisStore)
+
+	events := pendingEvents(t, s, topic,
+		models.Event{EventID: "a", UserID: 1, ItemID: 4, EventType: models.EventTypePurchase})
+	s.flush(ctx, events)
+
+	purchased, err := redisStore.GetPurchasedItemsSince(ctx, 1, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4"}, purchased)
+}
+
+func TestFlush_FailedBatchIsNotCommitted(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisStore, err := store.NewRedisStore(config.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisStore.Close() })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, topic := newTestBatchService(t, failingBatchStore{RecoSignalStore: redisStore, cancel: cancel})
+
+	events := pendingEvents(t, s, topic,
+		models.Event{EventID: "a", UserID: 1, ItemID: 1, EventType: models.EventTypeView})
+	s.flush(ctx, events)
+
+	assert.Equal(t, int64(0), topic.Committed())
+	assert.False(t, mr.Exists("dedup:processed:a"), "claim is released for the redelivery")
+	assert.False(t, mr.Exists("item:popularity"))
+}
+
+func TestFlush_DeadLettersEventsThatKeepFailing(t *testing.T) {
+	ctx := context.Background()
+	redisStore := newTestRedisStore(t)
+	s, topic := newTestBatchService(t, poisonBatchStore{redisStore, 666})
+	s.cfg.Processing.Retry.MaxAttempts = 2
+	s.cfg.Kafka.Topics.DeadLetter = "events.dlq"
+	dlq := stream.NewMemoryTopic("events.dlq")
+	s.dlqPublisher = dlq
+
+	events := pendingEvents(t, s, topic,
+		models.Event{EventID: "a", UserID: 1, ItemID: 1, EventType: models.EventTypeView},
+		models.Event{EventID: "b", UserID: 2, ItemID: 666, EventType: models.EventTypeView},
+		models.Event{EventID: "c", UserID: 3, ItemID: 3, EventType: models.EventTypeView})
+	s.flush(ctx, events)
+
+	assert.Equal(t, int64(3), topic.Committed(), "the partition keeps moving")
+	popular, err := redisStore.GetPopularItems(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "3"}, {Score: 1, Member: "1"}}, popular)
+
+	dead := dlq.Messages()
+	require.Len(t, dead, 1)
+	assert.Equal(t, events[1].job.msg.Offset, headerInt(t, dead[0], HeaderOriginalOffset))
+	assert.Equal(t, int64(2), headerInt(t, dead[0], HeaderAttempts))
+}
+
+func TestApplyBatch_SkipsEventsAppliedConcurrently(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisStore, err := store.NewRedisStore(config.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisStore.Close() })
+	s, topic := newTestBatchService(t, &racingBatchStore{RecoSignalStore: redisStore})
+
+	events := pendingEvents(t, s, topic,
+		models.Event{EventID: "a", UserID: 1, ItemID: 1, EventType: models.EventTypeView},
+		models.Event{EventID: "b", UserID: 2, ItemID: 2, EventType: models.EventTypeView},
+		models.Event{EventID: "b", UserID: 2, ItemID: 2, EventType: models.EventTypeView})
+
+	isNew, err := s.applyBatch(ctx, events)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true, false}, isNew, "a was applied by another consumer, b is repeated")
+
+	popular, err := redisStore.GetPopularItems(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "2"}}, popular)
+	assert.True(t, mr.Exists("dedup:processed:b"))
+}
+
+func TestBuildSignalBatch_CoViewWindow(t *testing.T) {
+	s, topic := newTestBatchService(t, store.NewMemorySignalStore())
+	s.cfg.Processing.CoviewWindow = 10
+	s.cfg.Processing.CoviewMaxGap = 30 * time.Minute
+	s.cfg.Processing.CoviewSameSession = true
+	s.cfg.Processing.CoviewDecay = 0.5
+
+	now := time.Now()
+	recent := map[int64][]store.RecentItem{1: {
+		{ItemID: 7, EventType: models.EventTypeView, SessionID: "s1", TouchedAt: now.Add(-10 * time.Minute)},
+		{ItemID: 8, EventType: models.EventTypeView, SessionID: "s0", TouchedAt: now.Add(-15 * time.Minute)},
+		{ItemID: 9, EventType: models.EventTypeView, SessionID: "s1", TouchedAt: now.Add(-2 * time.Hour)},
+		{ItemID: 6, EventType: models.EventTypeView, TouchedAt: now.Add(-20 * time.Minute)},
+	}}
+	events := pendingEvents(t, s, topic,
+		models.Event{EventID: "a", UserID: 1, ItemID: 1, SessionID: "s1", EventType: models.EventTypeView, Timestamp: now.Add(-time.Minute)},
+		models.Event{EventID: "b", UserID: 1, ItemID: 2, SessionID: "s1", EventType: models.EventTypeView, Timestamp: now},
+	)
+	batch := s.buildSignalBatch(events, []bool{true, true}, recent)
+
+	// Item 8 is from another session, item 9 is too old and item 6 has no
+	// session so only the time window applies. Weights halve with each step.
+	assert.Equal(t, map[int64]float64{2: 1, 7: 1, 6: 0.125}, batch.Related[store.RelationCoView][1])
+	assert.Equal(t, map[int64]float64{1: 1, 7: 0.5, 6: 0.0625}, batch.Related[store.RelationCoView][2])
+	assert.Equal(t, map[int64]float64{1: 1, 2: 0.5}, batch.Related[store.RelationCoView][7])
+	assert.NotContains(t, batch.Related[store.RelationCoView], int64(8))
+	assert.NotContains(t, batch.Related[store.RelationCoView], int64(9))
+
+	// Transitions only follow each event from the one right before it
+	assert.Equal(t, map[int64]map[int64]float64{7: {1: 1}, 1: {2: 1}}, batch.Related[store.RelationTransition])
+}
+
+func TestBuildSignalBatch_NonPositiveCoViewWindow(t *testing.T) {
+	for _, window := range []int{0, -1} {
+		s, topic := newTestBatchService(t, store.NewMemorySignalStore())
+		s.cfg.Processing.CoviewWindow = window
+
+		now := time.Now()
+		recent := map[int64][]store.RecentItem{1: {{ItemID: 7, EventType: models.EventTypeView, TouchedAt: now.Add(-time.Minute)}}}
+		events := pendingEvents(t, s, topic,
+			models.Event{EventID: "a", UserID: 1, ItemID: 1, EventType: models.EventTypeView, Timestamp: now},
+		)
+		batch := s.buildSignalBatch(events, []bool{true}, recent)
+
+		// Only the event itself fits the window, but transitions still follow
+		// the last event
+		assert.Empty(t, batch.Related[store.RelationCoView], "window %d", window)
+		assert.Equal(t, map[int64]map[int64]float64{7: {1: 1}}, batch.Related[store.RelationTransition], "window %d", window)
+	}
+}
+
+// failingBatchStore fails every batch and then cancels the flush, like a
+// shutdown during a Redis outage
+type failingBatchStore struct {
+	store.RecoSignalStore
+	cancel context.CancelFunc
+}
+
+func (f failingBatchStore) ApplySignalBatch(ctx context.Context, batch *store.SignalBatch) error {
+	f.cancel()
+	return errors.New("redis unavailable")
+}
+
+// racingBatchStore marks the first event of the first batch as seen right
+// before applying it, like a concurrent consumer would
+type racingBatchStore struct {
+	store.RecoSignalStore
+	raced bool
+}
+
+func (r *racingBatchStore) ApplySignalBatch(ctx context.Context, batch *store.SignalBatch) error {
+	if !r.raced {
+		r.raced = true
+		if _, err := r.RecoSignalStore.MarkEventSeen(ctx, batch.SeenScope, batch.Seen[0], time.Hour); err != nil {
+			return err
+		}
+	}
+	return r.RecoSignalStore.ApplySignalBatch(ctx, batch)
+}
+
+// poisonBatchStore fails every batch holding the poison item
+type poisonBatchStore struct {
+	store.RecoSignalStore
+	poison int64
+}
+
+func (p poisonBatchStore) ApplySignalBatch(ctx context.Context, batch *store.SignalBatch) error {
+	if _, ok := batch.Popularity[p.poison]; ok {
+		return errors.New("cannot apply item")
+	}
+	return p.RecoSignalStore.ApplySignalBatch(ctx, batch)
+}
@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+	"github.com/yourusername/reco-engine/internal/util/tracing"
+	"go.uber.org/zap"
+)
+
+// Headers attached to messages published to the dead-letter topic
+const (
+	HeaderError             = "x-error"
+	HeaderAttempts          = "x-attempts"
+	HeaderFailedAt          = "x-failed-at"
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+)
+
+// deadLetter publishes the original message with error metadata to the
+// dead-letter topic. Publishing is retried until it succeeds or ctx is
+// cancelled, so a failed message is never committed without being kept.
+func (s *Service) deadLetter(ctx context.Context, msg kafka.Message, cause error, attempts int) error {
+	if s.dlqPublisher == nil {
+		logger.Error("Dropping message after failed processing, no dead-letter topic configured",
+			zap.Error(cause),
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset))
+		return nil
+	}
+
+	dlqMsg := kafka.Message{
+		Key:   msg.Key,
+		Value: msg.Value,
+		Headers: append(append([]kafka.Header{}, msg.Headers...),
+			kafka.Header{Key: HeaderError, Value: []byte(cause.Error())},
+			kafka.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+			kafka.Header{Key: HeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+			kafka.Header{Key: HeaderOriginalTopic, Value: []byte(msg.Topic)},
+			kafka.Header{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+			kafka.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		),
+	}
+
+	// Point the trace context at the failed processing span
+	tracing.InjectKafka(ctx, &dlqMsg)
+
+	topic := s.cfg.Kafka.Topics.DeadLetter
+	for attempt := 1; ; attempt++ {
+		err := s.dlqPublisher.WriteMessages(ctx, dlqMsg)
+		if err == nil {
+			break
+		}
+
+		metrics.KafkaPublishErrors.WithLabelValues(topic).Inc()
+		logger.Error("Failed to publish to dead-letter topic", zap.Error(err), zap.Int("attempt", attempt))
+		backoff := s.cfg.Processing.Retry.Backoff(attempt)
+		if backoff <= 0 {
+			backoff = time.Second
+		}
+		if sleepErr := sleepContext(ctx, backoff); sleepErr != nil {
+			return sleepErr
+		}
+	}
+
+	metrics.EventsDeadLettered.WithLabelValues(msg.Topic).Inc()
+	metrics.KafkaMessagesPublished.WithLabelValues(topic).Inc()
+	logger.Warn("Message sent to dead-letter topic",
+		zap.Error(cause),
+		zap.String("dlq_topic", topic),
+		zap.Int("partition", msg.Partition),
+		zap.Int64("offset", msg.Offset),
+		zap.Int("attempts", attempts))
+
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
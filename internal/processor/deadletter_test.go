@@ -0,0 +1,86 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/stream"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+)
+
+func TestDeadLetter_AddsErrorMetadata(t *testing.T) {
+	logger.Init("error", "console")
+	dlq := stream.NewMemoryTopic("events.dlq")
+	s := &Service{
+		cfg:          &config.Config{Kafka: config.KafkaConfig{Topics: config.TopicConfig{DeadLetter: "events.dlq"}}},
+		dlqPublisher: dlq,
+	}
+
+	msg := kafka.Message{
+		Topic:     "events",
+		Partition: 3,
+		Offset:    42,
+		Key:       []byte("7"),
+		Value:     []byte(`{"user_id":7}`),
+		Headers:   []kafka.Header{{Key: "traceparent", Value: []byte("00-abc")}},
+	}
+	before := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, s.deadLetter(context.Background(), msg, errors.New("boom"), 3))
+
+	dead := dlq.Messages()
+	require.Len(t, dead, 1)
+	assert.Equal(t, msg.Key, dead[0].Key)
+	assert.Equal(t, msg.Value, dead[0].Value)
+	assert.Equal(t, "00-abc", header(t, dead[0], "traceparent"), "original headers are kept")
+	assert.Equal(t, "boom", header(t, dead[0], HeaderError))
+	assert.Equal(t, int64(3), headerInt(t, dead[0], HeaderAttempts))
+	assert.Equal(t, "events", header(t, dead[0], HeaderOriginalTopic))
+	assert.Equal(t, int64(3), headerInt(t, dead[0], HeaderOriginalPartition))
+	assert.Equal(t, int64(42), headerInt(t, dead[0], HeaderOriginalOffset))
+
+	failedAt, err := time.Parse(time.RFC3339Nano, header(t, dead[0], HeaderFailedAt))
+	require.NoError(t, err)
+	assert.False(t, failedAt.Before(before))
+}
+
+func TestHandleMessage_DeadLettersMalformedMessages(t *testing.T) {
+	logger.Init("error", "console")
+	dlq := stream.NewMemoryTopic("events.dlq")
+	s := &Service{
+		cfg:          &config.Config{Kafka: config.KafkaConfig{Topics: config.TopicConfig{DeadLetter: "events.dlq"}}},
+		dlqPublisher: dlq,
+	}
+
+	// Undecodable messages are not retried
+	p, err := s.handleMessage(context.Background(), job{msg: kafka.Message{Topic: "events", Value: []byte("not-json")}})
+	require.NoError(t, err)
+	assert.Nil(t, p)
+	dead := dlq.Messages()
+	require.Len(t, dead, 1)
+	assert.Equal(t, int64(1), headerInt(t, dead[0], HeaderAttempts))
+}
+
+// header returns the value of a header of a dead-lettered message
+func header(t *testing.T, msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	t.Fatalf("header %s not set", key)
+	return ""
+}
+
+// headerInt parses a numeric header of a dead-lettered message
+func headerInt(t *testing.T, msg kafka.Message, key string) int64 {
+	v, err := strconv.ParseInt(header(t, msg, key), 10, 64)
+	require.NoError(t, err)
+	return v
+}
@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// popularityDecayLease is the lease name that lets one processor decay scores per interval
+	popularityDecayLease = "popularity_decay"
+
+	// minPopularityScore is the score below which decayed items are removed
+	minPopularityScore = 0.01
+)
+
+// runPopularityDecay periodically scales down item popularity so that old
+// activity fades. The factor is derived from the time since the previous
+// decay, so scores follow the configured half-life regardless of how many
+// processor instances run or how late a tick fires.
+func (s *Service) runPopularityDecay(ctx context.Context) {
+	interval := s.cfg.Recommendation.PopularityDecayInterval
+	if interval <= 0 || s.cfg.Recommendation.PopularityDecayFactor(interval) >= 1 {
+		logger.Info("Popularity decay disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			acquired, err := s.redisStore.AcquireLease(ctx, popularityDecayLease, interval/2)
+			if err != nil {
+				logger.Error("Failed to acquire popularity decay lease", zap.Error(err))
+				continue
+			}
+			if !acquired {
+				continue
+			}
+			if err := s.decayPopularity(ctx, time.Now()); err != nil {
+				logger.Error("Failed to decay popularity", zap.Error(err))
+			}
+		}
+	}
+}
+
+// decayPopularity applies the decay accumulated since the previous run
+func (s *Service) decayPopularity(ctx context.Context, now time.Time) error {
+	last, err := s.redisStore.GetPopularityDecayedAt(ctx)
+	if err != nil {
+		return err
+	}
+
+	factor := 1.0
+	if !last.IsZero() {
+		factor = s.cfg.Recommendation.PopularityDecayFactor(now.Sub(last))
+	}
+
+	if err := s.redisStore.DecayPopularity(ctx, factor, minPopularityScore, now); err != nil {
+		return err
+	}
+
+	logger.Debug("Popularity decayed", zap.Float64("factor", factor))
+	return nil
+}
@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/models"
+	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+func newTestRedisStore(t *testing.T) *store.RedisStore {
+	mr := miniredis.RunT(t)
+	redisStore, err := store.NewRedisStore(config.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisStore.Close() })
+	return redisStore
+}
+
+func TestPopularityDecayFactor(t *testing.T) {
+	cfg := config.RecommendationConfig{PopularityHalfLife: 24 * time.Hour}
+	assert.InDelta(t, 0.5, cfg.PopularityDecayFactor(24*time.Hour), 1e-9)
+	assert.InDelta(t, 0.125, cfg.PopularityDecayFactor(72*time.Hour), 1e-9)
+	assert.Equal(t, 1.0, cfg.PopularityDecayFactor(0))
+
+	// Without a half-life the legacy per-interval factor is used
+	cfg = config.RecommendationConfig{PopularityDecay: 0.9, PopularityDecayInterval: time.Hour}
+	assert.InDelta(t, 0.81, cfg.PopularityDecayFactor(2*time.Hour), 1e-9)
+
+	assert.Equal(t, 1.0, config.RecommendationConfig{}.PopularityDecayFactor(time.Hour))
+}
+
+func TestDecayPopularity_OldActivityFades(t *testing.T) {
+	ctx := context.Background()
+	redisStore := newTestRedisStore(t)
+	svc := &Service{
+		redisStore: redisStore,
+		cfg: &config.Config{Recommendation: config.RecommendationConfig{
+			PopularityHalfLife: 24 * time.Hour,
+		}},
+	}
+
+	start := time.Now()
+	require.NoError(t, svc.decayPopularity(ctx, start))
+
+	// Item 1 was hot a week ago
+	addPopularity(t, redisStore, 1, 100)
+
+	// A week of hourly decay ticks
+	now := start
+	for i := 0; i < 7*24; i++ {
+		now = now.Add(time.Hour)
+		require.NoError(t, svc.decayPopularity(ctx, now))
+	}
+
+	// Item 2 has modest recent activity
+	addPopularity(t, redisStore, 2, 5)
+
+	popular, err := redisStore.GetPopularItems(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, popular, 2)
+	assert.Equal(t, "2", popular[0].Member)
+	assert.Equal(t, "1", popular[1].Member)
+	assert.InDelta(t, 100.0/128, popular[1].Score, 1e-6)
+
+	// Another month without activity removes item 1 entirely
+	require.NoError(t, svc.decayPopularity(ctx, now.Add(30*24*time.Hour)))
+	popular, err = redisStore.GetPopularItems(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, popular)
+}
+
+func addPopularity(t *testing.T, redisStore store.RecoSignalStore, itemID int64, weight float64) {
+	batch := store.NewSignalBatch(0, 0)
+	batch.AddPopularity(itemID, weight)
+	require.NoError(t, redisStore.ApplySignalBatch(context.Background(), batch))
+}
+
+func TestReplay_DecaysPopularityWithEventTime(t *testing.T) {
+	ctx := context.Background()
+	redisStore := store.NewMemorySignalStore()
+	svc := NewService(&config.Config{
+		Processing: config.ProcessingConfig{BatchSize: 10, RecentItemsLimit: 50},
+		Recommendation: config.RecommendationConfig{
+			PopularityHalfLife:      time.Hour,
+			PopularityDecayInterval: time.Hour,
+		},
+		EventWeights: config.EventWeightsConfig{View: 1},
+	}, nil, nil, redisStore, store.NewMemoryCatalogStore())
+
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	events := []models.Event{
+		{UserID: 1, ItemID: 1, EventType: models.EventTypeView, Timestamp: start},
+		{UserID: 2, ItemID: 2, EventType: models.EventTypeView, Timestamp: start.Add(2 * time.Hour)},
+	}
+	err := svc.Replay(ctx, start.Add(3*time.Hour), func(fn func(*models.Event) error) error {
+		for i := range events {
+			if err := fn(&events[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Item 1 went through three hourly ticks, item 2 through one
+	popular, err := redisStore.GetPopularItems(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, popular, 2)
+	assert.Equal(t, "2", popular[0].Member)
+	assert.InDelta(t, 0.5, popular[0].Score, 1e-9)
+	assert.InDelta(t, 0.125, popular[1].Score, 1e-9)
+}
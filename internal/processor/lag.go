@@ -0,0 +1,79 @@
+package processor
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+)
+
+// consumerStatsInterval is how often reader stats are exported
+const consumerStatsInterval = 10 * time.Second
+
+// statsReader is implemented by *kafka.Reader
+type statsReader interface {
+	Stats() kafka.ReaderStats
+}
+
+// runConsumerStats periodically exports Reader.Stats() when the consumer is
+// a Kafka reader. For consumer-group readers kafka-go aggregates the stats of
+// all assigned partitions under partition "-1"; exact per-partition lag is
+// recorded from each fetched message by recordLag.
+func (s *Service) runConsumerStats(ctx context.Context) {
+	reader, ok := s.consumer.(statsReader)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(consumerStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			recordReaderStats(reader.Stats())
+		}
+	}
+}
+
+func recordReaderStats(stats kafka.ReaderStats) {
+	gauges := map[string]int64{
+		"lag":            stats.Lag,
+		"offset":         stats.Offset,
+		"queue_length":   stats.QueueLength,
+		"queue_capacity": stats.QueueCapacity,
+	}
+	for stat, value := range gauges {
+		metrics.KafkaReaderStats.WithLabelValues(stats.Topic, stats.Partition, stat).Set(float64(value))
+	}
+
+	// Counters in ReaderStats are reset by every Stats() call
+	events := map[string]int64{
+		"dials":      stats.Dials,
+		"fetches":    stats.Fetches,
+		"messages":   stats.Messages,
+		"rebalances": stats.Rebalances,
+		"timeouts":   stats.Timeouts,
+		"errors":     stats.Errors,
+	}
+	for event, value := range events {
+		metrics.KafkaReaderEvents.WithLabelValues(stats.Topic, event).Add(float64(value))
+	}
+}
+
+// recordLag sets the lag of the message's partition from the high-water mark
+// the broker reported when the message was fetched
+func recordLag(msg kafka.Message) {
+	if msg.HighWaterMark <= 0 {
+		return
+	}
+	lag := msg.HighWaterMark - msg.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	metrics.KafkaConsumerLag.WithLabelValues(msg.Topic, strconv.Itoa(msg.Partition)).Set(float64(lag))
+}
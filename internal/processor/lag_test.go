@@ -0,0 +1,34 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/stream"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+)
+
+func TestRecordLag(t *testing.T) {
+	ctx := context.Background()
+	topic := stream.NewMemoryTopic("lag-test")
+	defer topic.Close()
+
+	require.NoError(t, topic.WriteMessages(ctx, kafka.Message{}, kafka.Message{}, kafka.Message{}))
+	lag := metrics.KafkaConsumerLag.WithLabelValues("lag-test", "0")
+
+	msg, err := topic.FetchMessage(ctx)
+	require.NoError(t, err)
+	recordLag(msg)
+	assert.Equal(t, 2.0, testutil.ToFloat64(lag))
+
+	for i := 0; i < 2; i++ {
+		msg, err = topic.FetchMessage(ctx)
+		require.NoError(t, err)
+	}
+	recordLag(msg)
+	assert.Equal(t, 0.0, testutil.ToFloat64(lag))
+}
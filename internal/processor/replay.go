@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/reco-engine/internal/models"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Replay applies the logged events that scan passes to fn, oldest first, in
+// batches of processing.batch_size, the way they are applied when consumed
+// from Kafka. Popularity is decayed every popularity_decay_interval of event
+// time up to until, so the signals match what the processor would have held
+// at until. It is used to rebuild signals offline, for example for
+// evaluation.
+func (s *Service) Replay(ctx context.Context, until time.Time, scan func(fn func(*models.Event) error) error) error {
+	size := s.cfg.Processing.BatchSize
+	if size < 1 {
+		size = 1
+	}
+
+	batch := make([]*pendingEvent, 0, size)
+	apply := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := s.applyBatch(ctx, batch); err != nil {
+			return fmt.Errorf("failed to apply events: %w", err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	// Decay ticks start one interval after the first event, as if the
+	// processor had started then
+	interval := s.cfg.Recommendation.PopularityDecayInterval
+	decays := interval > 0 && s.cfg.Recommendation.PopularityDecayFactor(interval) < 1
+	var nextDecay time.Time
+	decayUntil := func(t time.Time) error {
+		if !decays || nextDecay.IsZero() {
+			return nil
+		}
+		for !t.Before(nextDecay) {
+			if err := apply(); err != nil {
+				return err
+			}
+			if err := s.decayPopularity(ctx, nextDecay); err != nil {
+				return fmt.Errorf("failed to decay popularity: %w", err)
+			}
+			nextDecay = nextDecay.Add(interval)
+		}
+		return nil
+	}
+
+	i := 0
+	err := scan(func(e *models.Event) error {
+		event := *e
+		if decays && nextDecay.IsZero() {
+			if err := s.decayPopularity(ctx, event.Timestamp); err != nil {
+				return fmt.Errorf("failed to decay popularity: %w", err)
+			}
+			nextDecay = event.Timestamp.Add(interval)
+		}
+		if err := decayUntil(event.Timestamp); err != nil {
+			return err
+		}
+
+		p := &pendingEvent{event: event, dedupID: event.EventID, span: trace.SpanFromContext(ctx)}
+		if p.dedupID == "" {
+			p.dedupID = fmt.Sprintf("replay:%d:%d", event.ID, i)
+		}
+		i++
+		s.processEvent(ctx, p)
+
+		batch = append(batch, p)
+		if len(batch) == size {
+			return apply()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := apply(); err != nil {
+		return err
+	}
+	return decayUntil(until)
+}
@@ -3,29 +3,45 @@ package processor
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strconv"
+	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/segmentio/kafka-go"
 	"github.com/yourusername/reco-engine/internal/models"
 	"github.com/yourusername/reco-engine/internal/store"
+	"github.com/yourusername/reco-engine/internal/stream"
+	"github.com/yourusername/reco-engine/internal/util/cache"
 	"github.com/yourusername/reco-engine/internal/util/config"
 	"github.com/yourusername/reco-engine/internal/util/logger"
 	"github.com/yourusername/reco-engine/internal/util/metrics"
+	"github.com/yourusername/reco-engine/internal/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// dedupScope is the Redis dedup namespace for events applied by the processor
+const dedupScope = "processed"
+
 // Service handles stream processing
 type Service struct {
-	kafkaReader *kafka.Reader
-	redisStore  *store.RedisStore
-	cfg         *config.Config
+	consumer      stream.EventConsumer
+	dlqPublisher  stream.EventPublisher
+	redisStore    store.RecoSignalStore
+	pgStore       store.CatalogStore
+	categoryCache *cache.LRU[int64, string]
+	offsets       *offsetTracker
+	commitMu      sync.Mutex
+	cfg           *config.Config
 }
 
-// NewService creates a new processor service
-func NewService(cfg *config.Config, redisStore *store.RedisStore) *Service {
-	reader := kafka.NewReader(kafka.ReaderConfig{
+// NewKafkaReader creates the consumer-group reader for the events topic
+func NewKafkaReader(cfg *config.Config) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
 		Brokers:        cfg.Kafka.Brokers,
 		Topic:          cfg.Kafka.Topics.Events,
 		GroupID:        cfg.Kafka.ConsumerGroup,
@@ -34,138 +50,207 @@ func NewService(cfg *config.Config, redisStore *store.RedisStore) *Service {
 		CommitInterval: time.Second,
 		StartOffset:    kafka.LastOffset,
 	})
+}
+
+// NewDeadLetterWriter creates the writer for the dead-letter topic
+func NewDeadLetterWriter(cfg *config.Config) *kafka.Writer {
+	return kafka.NewWriter(kafka.WriterConfig{
+		Brokers:      cfg.Kafka.Brokers,
+		Topic:        cfg.Kafka.Topics.DeadLetter,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: int(kafka.RequireAll),
+	})
+}
 
+// NewService creates a new processor service. dlqPublisher may be nil, in
+// which case messages that keep failing are skipped instead of dead-lettered.
+func NewService(cfg *config.Config, consumer stream.EventConsumer, dlqPublisher stream.EventPublisher, redisStore store.RecoSignalStore, pgStore store.CatalogStore) *Service {
 	return &Service{
-		kafkaReader: reader,
-		redisStore:  redisStore,
-		cfg:         cfg,
+		consumer:      consumer,
+		dlqPublisher:  dlqPublisher,
+		redisStore:    redisStore,
+		pgStore:       pgStore,
+		categoryCache: cache.NewLRU[int64, string](cfg.Processing.CategoryCacheSize, cfg.Processing.CategoryCacheTTL),
+		offsets:       newOffsetTracker(),
+		cfg:           cfg,
 	}
 }
 
 // Close closes the service
 func (s *Service) Close() error {
-	return s.kafkaReader.Close()
+	if s.dlqPublisher != nil {
+		if err := s.dlqPublisher.Close(); err != nil {
+			logger.Error("Failed to close dead-letter publisher", zap.Error(err))
+		}
+	}
+	return s.consumer.Close()
 }
 
-// Start starts consuming and processing events
+// Start starts consuming and processing events. Messages are dispatched to
+// a pool of workers by key, their Redis updates are written in batches and
+// offsets are committed in partition order once their batch is flushed; it
+// returns once all workers have stopped.
 func (s *Service) Start(ctx context.Context) error {
-	logger.Info("Starting event processor")
+	workers := s.cfg.Processing.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	logger.Info("Starting event processor", zap.Int("workers", workers))
+
+	go s.runPopularityDecay(ctx)
+	go s.runConsumerStats(ctx)
+
+	batcher := make(chan *pendingEvent, workerQueueSize)
+	batcherDone := make(chan struct{})
+	go func() {
+		defer close(batcherDone)
+		s.runBatcher(ctx, batcher)
+	}()
+
+	queues := make([]chan job, workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan job, workerQueueSize)
+		wg.Add(1)
+		go func(queue <-chan job) {
+			defer wg.Done()
+			s.runWorker(ctx, queue, batcher)
+		}(queues[i])
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+		close(batcher)
+		<-batcherDone
+		logger.Info("Stopping event processor")
+	}()
 
 	for {
+		msg, err := s.consumer.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Error("Failed to fetch message", zap.Error(err))
+			continue
+		}
+		recordLag(msg)
+
+		j := s.offsets.track(msg)
 		select {
+		case queues[workerFor(msg, workers)] <- j:
 		case <-ctx.Done():
-			logger.Info("Stopping event processor")
 			return nil
-		default:
-			msg, err := s.kafkaReader.FetchMessage(ctx)
-			if err != nil {
-				if err == context.Canceled {
-					return nil
-				}
-				logger.Error("Failed to fetch message", zap.Error(err))
-				continue
-			}
+		}
+	}
+}
 
-			if err := s.processMessage(ctx, msg); err != nil {
-				logger.Error("Failed to process message",
-					zap.Error(err),
-					zap.String("key", string(msg.Key)))
-				metrics.EventProcessingErrors.Inc()
-			} else {
-				// Commit message
-				if err := s.kafkaReader.CommitMessages(ctx, msg); err != nil {
-					logger.Error("Failed to commit message", zap.Error(err))
-				}
-			}
+// pendingEvent is a decoded event waiting for the next flush. Its consumer
+// span stays open until the flush that applies it completes.
+type pendingEvent struct {
+	job       job
+	event     models.Event
+	dedupID   string
+	touchedAt time.Time
+	weight    float64
+	category  string
+	span      trace.Span
+}
 
-			metrics.KafkaMessagesConsumed.WithLabelValues(s.cfg.Kafka.Topics.Events).Inc()
-		}
+// end finishes the event's consumer span, recording err if the event was not applied
+func (p *pendingEvent) end(err error) {
+	if err != nil {
+		tracing.RecordError(p.span, err)
 	}
+	p.span.End()
 }
 
-func (s *Service) processMessage(ctx context.Context, msg kafka.Message) error {
+// handleMessage decodes a message into an event for the next flush under a
+// consumer span continuing the trace started by ingest. A message that cannot
+// be decoded is sent to the dead-letter topic instead, in which case a nil
+// event is returned and the message may be committed right away.
+func (s *Service) handleMessage(ctx context.Context, j job) (*pendingEvent, error) {
+	msg := j.msg
+	ctx = tracing.ExtractKafka(ctx, &msg)
+	ctx, span := tracing.Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(tracing.KafkaAttributes(msg.Topic)...),
+		trace.WithAttributes(
+			semconv.MessagingKafkaDestinationPartition(msg.Partition),
+			semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+		))
+
 	var event models.Event
 	if err := json.Unmarshal(msg.Value, &event); err != nil {
-		return fmt.Errorf("failed to unmarshal event: %w", err)
+		defer span.End()
+		err = fmt.Errorf("failed to unmarshal event: %w", err)
+		metrics.EventProcessingErrors.Inc()
+		tracing.RecordError(span, err)
+		logger.Warn("Failed to decode message",
+			zap.Error(err),
+			zap.String("key", string(msg.Key)),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset))
+		return nil, s.deadLetter(ctx, msg, err, 1)
 	}
 
-	// Process event based on type
-	if err := s.processEvent(ctx, &event); err != nil {
-		return fmt.Errorf("failed to process event: %w", err)
+	p := &pendingEvent{
+		job:     j,
+		event:   event,
+		dedupID: eventDedupID(msg, &event),
+		span:    span,
 	}
-
-	metrics.EventsProcessed.WithLabelValues(event.EventType).Inc()
-
-	logger.Debug("Event processed",
-		zap.Int64("user_id", event.UserID),
-		zap.Int64("item_id", event.ItemID),
-		zap.String("event_type", event.EventType))
-
-	return nil
+	s.processEvent(ctx, p)
+	return p, nil
 }
 
-func (s *Service) processEvent(ctx context.Context, event *models.Event) error {
-	// 1. Update user recent items
-	if err := s.redisStore.AddRecentItem(ctx, event.UserID, event.ItemID, s.cfg.Processing.RecentItemsLimit); err != nil {
-		logger.Error("Failed to add recent item", zap.Error(err))
+// eventDedupID returns the client event ID when present, otherwise the Kafka
+// message position, which stays the same when a message is redelivered
+func eventDedupID(msg kafka.Message, event *models.Event) string {
+	if event.EventID != "" {
+		return event.EventID
 	}
+	return fmt.Sprintf("%s:%d:%d", msg.Topic, msg.Partition, msg.Offset)
+}
 
-	// 2. Update item popularity with weighted score
-	weight := s.getEventWeight(event.EventType)
-	if err := s.redisStore.IncrPopularity(ctx, event.ItemID, weight); err != nil {
-		logger.Error("Failed to increment popularity", zap.Error(err))
-	}
+// processEvent resolves everything the flush needs to apply the event that
+// is not in the event itself
+func (s *Service) processEvent(ctx context.Context, p *pendingEvent) {
+	event := &p.event
+	ctx, span := tracing.Start(ctx, "processEvent", trace.WithAttributes(
+		attribute.String("event.type", event.EventType),
+		attribute.Int64("user.id", event.UserID),
+		attribute.Int64("item.id", event.ItemID),
+	))
+	defer span.End()
 
-	// 3. Update co-view counts
-	if err := s.updateCoView(ctx, event); err != nil {
-		logger.Error("Failed to update co-view", zap.Error(err))
+	p.touchedAt = event.Timestamp
+	if p.touchedAt.IsZero() {
+		p.touchedAt = time.Now()
 	}
-
-	return nil
+	p.weight = s.cfg.EventWeights.Weight(event.EventType)
+	p.category = s.itemCategory(ctx, event.ItemID)
 }
 
-func (s *Service) updateCoView(ctx context.Context, event *models.Event) error {
-	// Get user's recent items
-	recentItems, err := s.redisStore.GetRecentItems(ctx, event.UserID, s.cfg.Processing.CoviewWindow)
-	if err != nil {
-		return err
+// itemCategory returns the item's category through the in-process cache.
+// Unknown items are cached with an empty category so they are not looked up
+// on every event; lookup errors are not cached.
+func (s *Service) itemCategory(ctx context.Context, itemID int64) string {
+	if category, ok := s.categoryCache.Get(itemID); ok {
+		return category
 	}
 
-	// Update co-view for each recent item
-	for _, recentItemStr := range recentItems {
-		recentItemID, err := strconv.ParseInt(recentItemStr, 10, 64)
-		if err != nil {
-			continue
-		}
-
-		// Skip if same item
-		if recentItemID == event.ItemID {
-			continue
-		}
-
-		// Increment co-view count (bidirectional)
-		if err := s.redisStore.IncrCoView(ctx, event.ItemID, recentItemID); err != nil {
-			logger.Error("Failed to increment co-view", zap.Error(err))
-		}
-		if err := s.redisStore.IncrCoView(ctx, recentItemID, event.ItemID); err != nil {
-			logger.Error("Failed to increment co-view", zap.Error(err))
+	item, err := s.pgStore.GetItem(ctx, itemID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logger.Warn("Failed to look up item category", zap.Error(err), zap.Int64("item_id", itemID))
+			return ""
 		}
+		item = &models.Item{}
 	}
 
-	return nil
-}
-
-func (s *Service) getEventWeight(eventType string) float64 {
-	switch eventType {
-	case models.EventTypeView:
-		return s.cfg.EventWeights.View
-	case models.EventTypeClick:
-		return s.cfg.EventWeights.Click
-	case models.EventTypeCart:
-		return s.cfg.EventWeights.Cart
-	case models.EventTypePurchase:
-		return s.cfg.EventWeights.Purchase
-	default:
-		return 1.0
-	}
+	s.categoryCache.Set(itemID, item.Category)
+	return item.Category
 }
@@ -0,0 +1,157 @@
+package processor
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/yourusername/reco-engine/internal/util/logger"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+	"go.uber.org/zap"
+)
+
+// workerQueueSize is the number of messages buffered per worker before the
+// fetch loop blocks
+const workerQueueSize = 64
+
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// partitionOffsets holds the fetched but not yet committed messages of a
+// partition, in fetch order. A new one is started whenever the partition is
+// rewound, so completions from before the rewind can be told apart.
+type partitionOffsets struct {
+	pending []int64
+	done    map[int64]kafka.Message
+}
+
+// job is a fetched message together with the partition state it was tracked in
+type job struct {
+	msg       kafka.Message
+	partition *partitionOffsets
+}
+
+// offsetTracker decides which offsets may be committed when messages of a
+// partition complete out of order: only the longest prefix of completed
+// messages is committable.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition]*partitionOffsets
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[topicPartition]*partitionOffsets)}
+}
+
+// track records a fetched message. A message at or before the last tracked
+// offset means the partition was rewound (rebalance or redelivery), so its
+// older state is dropped.
+func (t *offsetTracker) track(msg kafka.Message) job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := topicPartition{msg.Topic, msg.Partition}
+	p, ok := t.partitions[key]
+	if !ok || (len(p.pending) > 0 && msg.Offset <= p.pending[len(p.pending)-1]) {
+		p = &partitionOffsets{done: make(map[int64]kafka.Message)}
+		t.partitions[key] = p
+	}
+	p.pending = append(p.pending, msg.Offset)
+	return job{msg: msg, partition: p}
+}
+
+// complete marks a job as done and returns the last message of the
+// completed prefix of its partition, if that prefix grew
+func (t *offsetTracker) complete(j job) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.partitions[topicPartition{j.msg.Topic, j.msg.Partition}]
+	if p == nil || p != j.partition {
+		// Tracked before a rewind; the message is redelivered
+		return kafka.Message{}, false
+	}
+	p.done[j.msg.Offset] = j.msg
+
+	var last kafka.Message
+	n := 0
+	for _, offset := range p.pending {
+		done, ok := p.done[offset]
+		if !ok {
+			break
+		}
+		last = done
+		delete(p.done, offset)
+		n++
+	}
+	if n == 0 {
+		return kafka.Message{}, false
+	}
+	p.pending = p.pending[n:]
+	return last, true
+}
+
+// workerFor spreads messages across workers by key so that all events of a
+// user are handled by the same worker, in order
+func workerFor(msg kafka.Message, workers int) int {
+	if len(msg.Key) == 0 {
+		return msg.Partition % workers
+	}
+	h := fnv.New32a()
+	h.Write(msg.Key)
+	return int(h.Sum32() % uint32(workers))
+}
+
+// runWorker decodes the messages of one worker queue and hands them to the
+// batcher until the queue is closed
+func (s *Service) runWorker(ctx context.Context, queue <-chan job, batcher chan<- *pendingEvent) {
+	for j := range queue {
+		msg := j.msg
+		if ctx.Err() != nil {
+			// Shutting down: leave the rest uncommitted for redelivery
+			continue
+		}
+
+		p, err := s.handleMessage(ctx, j)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error("Failed to handle message",
+					zap.Error(err),
+					zap.String("key", string(msg.Key)))
+			}
+			// The offset stays uncommitted and holds back later ones
+			continue
+		}
+		if p == nil {
+			// Dead-lettered
+			s.commit(ctx, j)
+			metrics.KafkaMessagesConsumed.WithLabelValues(s.cfg.Kafka.Topics.Events).Inc()
+			continue
+		}
+
+		select {
+		case batcher <- p:
+		case <-ctx.Done():
+			p.end(ctx.Err())
+		}
+	}
+}
+
+// commit marks the job as done and commits its partition up to the last message
+// whose predecessors are all done. Commits are serialized so that a
+// partition's committed offset never moves backwards.
+func (s *Service) commit(ctx context.Context, j job) {
+	s.commitMu.Lock()
+	defer s.commitMu.Unlock()
+
+	committable, ok := s.offsets.complete(j)
+	if !ok {
+		return
+	}
+	if err := s.consumer.CommitMessages(ctx, committable); err != nil {
+		logger.Error("Failed to commit message", zap.Error(err))
+	}
+}
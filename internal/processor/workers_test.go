@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffsetTracker_CommitsContiguousPrefix(t *testing.T) {
+	tracker := newOffsetTracker()
+	jobs := make(map[int64]job)
+	for offset := int64(10); offset < 14; offset++ {
+		jobs[offset] = tracker.track(kafka.Message{Topic: "events", Partition: 0, Offset: offset})
+	}
+	other := tracker.track(kafka.Message{Topic: "events", Partition: 1, Offset: 5})
+
+	// Later messages finishing first are held back
+	_, ok := tracker.complete(jobs[12])
+	assert.False(t, ok)
+	_, ok = tracker.complete(jobs[11])
+	assert.False(t, ok)
+
+	committable, ok := tracker.complete(jobs[10])
+	require.True(t, ok)
+	assert.Equal(t, int64(12), committable.Offset)
+
+	// Partitions are independent
+	committable, ok = tracker.complete(other)
+	require.True(t, ok)
+	assert.Equal(t, int64(5), committable.Offset)
+
+	committable, ok = tracker.complete(jobs[13])
+	require.True(t, ok)
+	assert.Equal(t, int64(13), committable.Offset)
+}
+
+func TestOffsetTracker_ResetsOnRewind(t *testing.T) {
+	tracker := newOffsetTracker()
+	tracker.track(kafka.Message{Partition: 0, Offset: 7})
+	stale := tracker.track(kafka.Message{Partition: 0, Offset: 8})
+
+	// Redelivery after a rebalance starts again from the committed offset
+	redelivered := tracker.track(kafka.Message{Partition: 0, Offset: 7})
+	again := tracker.track(kafka.Message{Partition: 0, Offset: 8})
+
+	// Completions tracked before the rewind are ignored
+	_, ok := tracker.complete(stale)
+	assert.False(t, ok)
+	committable, ok := tracker.complete(redelivered)
+	require.True(t, ok)
+	assert.Equal(t, int64(7), committable.Offset)
+
+	committable, ok = tracker.complete(again)
+	require.True(t, ok)
+	assert.Equal(t, int64(8), committable.Offset)
+}
+
+func TestWorkerFor_SameKeySameWorker(t *testing.T) {
+	a := kafka.Message{Key: []byte("42"), Partition: 0}
+	b := kafka.Message{Key: []byte("42"), Partition: 2}
+	assert.Equal(t, workerFor(a, 8), workerFor(b, 8))
+	assert.Equal(t, 0, workerFor(kafka.Message{}, 1))
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecentItem is an item a user touched, how and in which session
+type RecentItem struct {
+	ItemID    int64
+	EventType string
+	SessionID string
+	TouchedAt time.Time
+}
+
+// formatRecentEvent encodes a recent item for the user:recent_events list.
+// The session ID goes last as it may contain the separator.
+func formatRecentEvent(item RecentItem) string {
+	return fmt.Sprintf("%d:%d:%s:%s", item.TouchedAt.UnixMilli(), item.ItemID, item.EventType, item.SessionID)
+}
+
+// parseRecentEvent decodes an entry of the user:recent_events list
+func parseRecentEvent(value string) (RecentItem, error) {
+	parts := strings.SplitN(value, ":", 4)
+	if len(parts) != 4 {
+		return RecentItem{}, fmt.Errorf("malformed recent event %q", value)
+	}
+	millis, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return RecentItem{}, fmt.Errorf("malformed recent event %q: %w", value, err)
+	}
+	itemID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return RecentItem{}, fmt.Errorf("malformed recent event %q: %w", value, err)
+	}
+	return RecentItem{ItemID: itemID, EventType: parts[2], SessionID: parts[3], TouchedAt: time.UnixMilli(millis)}, nil
+}
+
+// Purchase is an item a user bought
+type Purchase struct {
+	ItemID      int64
+	PurchasedAt time.Time
+}
+
+// ErrEventsSeen is returned by ApplySignalBatch when one of the event IDs the
+// batch marks as seen was recorded since it was checked. Nothing is applied.
+var ErrEventsSeen = errors.New("events already recorded as seen")
+
+// SignalBatch holds signal updates merged from many events so that they can
+// be applied in a single round trip. Increments to the same key are summed.
+type SignalBatch struct {
+	RecentItems        map[int64][]RecentItem // per user, oldest first
+	RecentLimit        int
+	Popularity         map[int64]float64
+	CategoryPopularity map[string]map[int64]float64
+	Purchases          map[int64][]Purchase // per user
+	PurchaseRetention  time.Duration
+	Related            map[string]map[int64]map[int64]float64 // per relation
+
+	// Event IDs recorded under SeenScope for SeenTTL together with the updates
+	Seen      []string
+	SeenScope string
+	SeenTTL   time.Duration
+}
+
+// NewSignalBatch creates an empty batch. recentLimit bounds each user's recent
+// items and purchaseRetention is how long purchases are kept.
+func NewSignalBatch(recentLimit int, purchaseRetention time.Duration) *SignalBatch {
+	return &SignalBatch{
+		RecentItems:        make(map[int64][]RecentItem),
+		RecentLimit:        recentLimit,
+		Popularity:         make(map[int64]float64),
+		CategoryPopularity: make(map[string]map[int64]float64),
+		Purchases:          make(map[int64][]Purchase),
+		PurchaseRetention:  purchaseRetention,
+		Related:            make(map[string]map[int64]map[int64]float64),
+	}
+}
+
+// AddRecentItem appends an item to the user's recent items
+func (b *SignalBatch) AddRecentItem(userID int64, item RecentItem) {
+	b.RecentItems[userID] = append(b.RecentItems[userID], item)
+}
+
+// AddPopularity adds weight to the item's global popularity
+func (b *SignalBatch) AddPopularity(itemID int64, weight float64) {
+	b.Popularity[itemID] += weight
+}
+
+// AddCategoryPopularity adds weight to the item's popularity within category
+func (b *SignalBatch) AddCategoryPopularity(category string, itemID int64, weight float64) {
+	items, ok := b.CategoryPopularity[category]
+	if !ok {
+		items = make(map[int64]float64)
+		b.CategoryPopularity[category] = items
+	}
+	items[itemID] += weight
+}
+
+// AddPurchase records a purchase in the user's history
+func (b *SignalBatch) AddPurchase(userID, itemID int64, purchasedAt time.Time) {
+	b.Purchases[userID] = append(b.Purchases[userID], Purchase{ItemID: itemID, PurchasedAt: purchasedAt})
+}
+
+// AddRelated adds weight to the score of itemID2 for itemID1 in the given
+// item-item relation
+func (b *SignalBatch) AddRelated(relation string, itemID1, itemID2 int64, weight float64) {
+	related, ok := b.Related[relation]
+	if !ok {
+		related = make(map[int64]map[int64]float64)
+		b.Related[relation] = related
+	}
+	items, ok := related[itemID1]
+	if !ok {
+		items = make(map[int64]float64)
+		related[itemID1] = items
+	}
+	items[itemID2] += weight
+}
+
+// MarkSeen records the event IDs under the dedup scope in the same
+// transaction as the updates, so that the events are either applied and
+// marked or neither
+func (b *SignalBatch) MarkSeen(scope string, eventIDs []string, ttl time.Duration) {
+	b.SeenScope = scope
+	b.Seen = append(b.Seen, eventIDs...)
+	b.SeenTTL = ttl
+}
+
+// Empty reports whether the batch has no updates
+func (b *SignalBatch) Empty() bool {
+	return len(b.RecentItems) == 0 && len(b.Popularity) == 0 && len(b.CategoryPopularity) == 0 &&
+		len(b.Purchases) == 0 && len(b.Related) == 0 && len(b.Seen) == 0
+}
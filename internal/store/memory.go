@@ -0,0 +1,718 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5"
+	"github.com/yourusername/reco-engine/internal/models"
+)
+
+// MemorySignalStore is an in-process RecoSignalStore for tests and local
+// tooling. It mirrors the Redis key layout of RedisStore; expiry is only
+// enforced for dedup keys, leases, cached recommendations and user factors.
+type MemorySignalStore struct {
+	mu      sync.Mutex
+	lists   map[string][]string
+	zsets   map[string]map[string]float64
+	values  map[string]string
+	expires map[string]time.Time
+	now     func() time.Time
+}
+
+// NewMemorySignalStore creates an empty in-memory signal store
+func NewMemorySignalStore() *MemorySignalStore {
+	return &MemorySignalStore{
+		lists:   make(map[string][]string),
+		zsets:   make(map[string]map[string]float64),
+		values:  make(map[string]string),
+		expires: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// GetRecentItems gets user's recent items
+func (m *MemorySignalStore) GetRecentItems(ctx context.Context, userID int64, count int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lrange(fmt.Sprintf("user:recent:%d", userID), count), nil
+}
+
+// GetRecentItemTimes gets user's most recently touched distinct items with
+// the time they were last touched, newest first
+func (m *MemorySignalStore) GetRecentItemTimes(ctx context.Context, userID int64, count int) ([]redis.Z, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.zrevrange(fmt.Sprintf("user:recent_ts:%d", userID), count), nil
+}
+
+// GetPurchasedItemsSince gets items the user purchased since the given time
+func (m *MemorySignalStore) GetPurchasedItemsSince(ctx context.Context, userID int64, since time.Time) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var items []string
+	for member, score := range m.zsets[fmt.Sprintf("user:purchased:%d", userID)] {
+		if score >= float64(since.Unix()) {
+			items = append(items, member)
+		}
+	}
+	sort.Strings(items)
+	return items, nil
+}
+
+// GetPopularItems gets top popular items
+func (m *MemorySignalStore) GetPopularItems(ctx context.Context, count int) ([]redis.Z, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.zrevrange(popularityKey, count), nil
+}
+
+// GetCategoryPopularItems gets top popular items of a category
+func (m *MemorySignalStore) GetCategoryPopularItems(ctx context.Context, category string, count int) ([]redis.Z, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.zrevrange(categoryPopularityKey(category), count), nil
+}
+
+// GetPopularityDecayedAt returns when popularity scores were last decayed
+func (m *MemorySignalStore) GetPopularityDecayedAt(ctx context.Context) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.get(popularityDecayedAtKey)
+	if !ok {
+		return time.Time{}, nil
+	}
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// DecayPopularity multiplies all global and per-category popularity scores by
+// factor, drops items whose score falls below minScore and records decayedAt
+func (m *MemorySignalStore) DecayPopularity(ctx context.Context, factor, minScore float64, decayedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prefix := popularityKey + ":"
+	for key, zs := range m.zsets {
+		if key != popularityKey && !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		for member, score := range zs {
+			score *= factor
+			if score < minScore {
+				delete(zs, member)
+				continue
+			}
+			zs[member] = score
+		}
+	}
+	m.values[popularityDecayedAtKey] = strconv.FormatInt(decayedAt.UnixNano(), 10)
+	return nil
+}
+
+// GetRelatedItems gets the top items of an item-item relation for the given item
+func (m *MemorySignalStore) GetRelatedItems(ctx context.Context, relation string, itemID int64, count int) ([]redis.Z, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.zrevrange(relatedKey(relation, itemID), count), nil
+}
+
+// SetItemKNN stores the precomputed k-nearest neighbors of an item under an
+// embedding model
+func (m *MemorySignalStore) SetItemKNN(ctx context.Context, modelID, itemID int64, neighbors []int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]string, len(neighbors))
+	for i, n := range neighbors {
+		list[i] = formatID(n)
+	}
+	m.lists[knnKey(modelID, itemID)] = list
+	return nil
+}
+
+// SetItemKNNs stores the precomputed k-nearest neighbors of many items under
+// an embedding model
+func (m *MemorySignalStore) SetItemKNNs(ctx context.Context, modelID int64, neighbors map[int64][]int64) error {
+	for itemID, list := range neighbors {
+		if err := m.SetItemKNN(ctx, modelID, itemID, list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetItemKNN gets the precomputed k-nearest neighbors of an item under an
+// embedding model
+func (m *MemorySignalStore) GetItemKNN(ctx context.Context, modelID, itemID int64, count int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lrange(knnKey(modelID, itemID), count), nil
+}
+
+// GetRecentEventsForUsers gets the recent items of several users, newest
+// first, with the session and time they were touched in
+func (m *MemorySignalStore) GetRecentEventsForUsers(ctx context.Context, userIDs []int64, count int) (map[int64][]RecentItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := make(map[int64][]RecentItem, len(userIDs))
+	for _, userID := range userIDs {
+		for _, value := range m.lrange(fmt.Sprintf("user:recent_events:%d", userID), count) {
+			if item, err := parseRecentEvent(value); err == nil {
+				events[userID] = append(events[userID], item)
+			}
+		}
+	}
+	return events, nil
+}
+
+// ApplySignalBatch applies all updates of the batch atomically, or returns
+// ErrEventsSeen without applying any if one of its seen event IDs is recorded
+func (m *MemorySignalStore) ApplySignalBatch(ctx context.Context, batch *SignalBatch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, eventID := range batch.Seen {
+		if _, ok := m.get(dedupKey(batch.SeenScope, eventID)); ok {
+			return ErrEventsSeen
+		}
+	}
+	for _, eventID := range batch.Seen {
+		m.set(dedupKey(batch.SeenScope, eventID), "1", batch.SeenTTL)
+	}
+
+	for userID, recent := range batch.RecentItems {
+		eventsKey := fmt.Sprintf("user:recent_events:%d", userID)
+		for _, item := range recent {
+			m.addRecentItem(userID, item.ItemID, batch.RecentLimit, item.TouchedAt)
+			m.lists[eventsKey] = append([]string{formatRecentEvent(item)}, m.lists[eventsKey]...)
+		}
+		if len(m.lists[eventsKey]) > batch.RecentLimit {
+			m.lists[eventsKey] = m.lists[eventsKey][:batch.RecentLimit]
+		}
+	}
+	for itemID, weight := range batch.Popularity {
+		m.zset(popularityKey)[formatID(itemID)] += weight
+	}
+	for category, items := range batch.CategoryPopularity {
+		for itemID, weight := range items {
+			m.zset(categoryPopularityKey(category))[formatID(itemID)] += weight
+		}
+	}
+	for userID, purchases := range batch.Purchases {
+		for _, purchase := range purchases {
+			m.addPurchasedItem(userID, purchase.ItemID, purchase.PurchasedAt, batch.PurchaseRetention)
+		}
+	}
+	for relation, related := range batch.Related {
+		for itemID, items := range related {
+			zs := m.zset(relatedKey(relation, itemID))
+			for otherID, weight := range items {
+				zs[formatID(otherID)] += weight
+			}
+		}
+	}
+	return nil
+}
+
+// MarkEventSeen records an event ID under the given dedup scope for ttl.
+// It returns false if the ID was already recorded within the window.
+func (m *MemorySignalStore) MarkEventSeen(ctx context.Context, scope, eventID string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setNX(dedupKey(scope, eventID), ttl), nil
+}
+
+// MarkEventsSeen records event IDs under the given dedup scope for ttl. It
+// reports for each ID whether it is new; an ID repeated within eventIDs is
+// new only the first time.
+func (m *MemorySignalStore) MarkEventsSeen(ctx context.Context, scope string, eventIDs []string, ttl time.Duration) ([]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	isNew := make([]bool, len(eventIDs))
+	for i, eventID := range eventIDs {
+		isNew[i] = m.setNX(dedupKey(scope, eventID), ttl)
+	}
+	return isNew, nil
+}
+
+// EventsSeen reports for each event ID whether it is recorded under the
+// given dedup scope
+func (m *MemorySignalStore) EventsSeen(ctx context.Context, scope string, eventIDs []string) ([]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := make([]bool, len(eventIDs))
+	for i, eventID := range eventIDs {
+		_, seen[i] = m.get(dedupKey(scope, eventID))
+	}
+	return seen, nil
+}
+
+// ForgetEvent removes an event ID from the given dedup scope
+func (m *MemorySignalStore) ForgetEvent(ctx context.Context, scope, eventID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.del(dedupKey(scope, eventID))
+	return nil
+}
+
+// AcquireLease takes a named lease for ttl and reports whether it was free
+func (m *MemorySignalStore) AcquireLease(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setNX(fmt.Sprintf("lease:%s", name), ttl), nil
+}
+
+// SetUserFactors stores the collaborative filtering factors of a model's
+// users for ttl
+func (m *MemorySignalStore) SetUserFactors(ctx context.Context, modelID int64, factors map[int64][]float64, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for userID, f := range factors {
+		m.set(userFactorsKey(modelID, userID), encodeFactors(f), ttl)
+	}
+	return nil
+}
+
+// GetUserFactors gets a user's factors of a model, returning redis.Nil if the
+// user has none
+func (m *MemorySignalStore) GetUserFactors(ctx context.Context, modelID, userID int64) ([]float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.get(userFactorsKey(modelID, userID))
+	if !ok {
+		return nil, redis.Nil
+	}
+	return decodeFactors(value)
+}
+
+// CacheRecommendations caches recommendations for a user
+func (m *MemorySignalStore) CacheRecommendations(ctx context.Context, userID int64, data string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(fmt.Sprintf("cache:reco:%d", userID), data, ttl)
+	return nil
+}
+
+// GetCachedRecommendations gets cached recommendations, returning redis.Nil on a miss
+func (m *MemorySignalStore) GetCachedRecommendations(ctx context.Context, userID int64) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.get(fmt.Sprintf("cache:reco:%d", userID))
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (m *MemorySignalStore) addRecentItem(userID, itemID int64, limit int, touchedAt time.Time) {
+	key := fmt.Sprintf("user:recent:%d", userID)
+	list := append([]string{formatID(itemID)}, m.lists[key]...)
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	m.lists[key] = list
+
+	tsKey := fmt.Sprintf("user:recent_ts:%d", userID)
+	m.zset(tsKey)[formatID(itemID)] = float64(touchedAt.UnixMilli())
+	m.trimZSet(tsKey, limit)
+}
+
+func (m *MemorySignalStore) addPurchasedItem(userID, itemID int64, purchasedAt time.Time, retention time.Duration) {
+	zs := m.zset(fmt.Sprintf("user:purchased:%d", userID))
+	zs[formatID(itemID)] = float64(purchasedAt.Unix())
+	cutoff := float64(m.now().Add(-retention).Unix())
+	for member, score := range zs {
+		if score < cutoff {
+			delete(zs, member)
+		}
+	}
+}
+
+func (m *MemorySignalStore) zset(key string) map[string]float64 {
+	zs, ok := m.zsets[key]
+	if !ok {
+		zs = make(map[string]float64)
+		m.zsets[key] = zs
+	}
+	return zs
+}
+
+// zrevrange returns members by descending score, ties broken by descending
+// member like Redis ZREVRANGE
+func (m *MemorySignalStore) zrevrange(key string, count int) []redis.Z {
+	zs := m.zsets[key]
+	result := make([]redis.Z, 0, len(zs))
+	for member, score := range zs {
+		result = append(result, redis.Z{Score: score, Member: member})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Member.(string) > result[j].Member.(string)
+	})
+	if count >= 0 && len(result) > count {
+		result = result[:count]
+	}
+	return result
+}
+
+// trimZSet keeps only the limit highest scored members
+func (m *MemorySignalStore) trimZSet(key string, limit int) {
+	zs := m.zsets[key]
+	if len(zs) <= limit {
+		return
+	}
+	keep := make(map[string]float64, limit)
+	for _, z := range m.zrevrange(key, limit) {
+		keep[z.Member.(string)] = z.Score
+	}
+	m.zsets[key] = keep
+}
+
+func (m *MemorySignalStore) lrange(key string, count int) []string {
+	list := m.lists[key]
+	if count >= 0 && len(list) > count {
+		list = list[:count]
+	}
+	return append([]string(nil), list...)
+}
+
+func (m *MemorySignalStore) get(key string) (string, bool) {
+	if exp, ok := m.expires[key]; ok && m.now().After(exp) {
+		m.del(key)
+		return "", false
+	}
+	value, ok := m.values[key]
+	return value, ok
+}
+
+func (m *MemorySignalStore) set(key, value string, ttl time.Duration) {
+	m.values[key] = value
+	if ttl > 0 {
+		m.expires[key] = m.now().Add(ttl)
+	} else {
+		delete(m.expires, key)
+	}
+}
+
+func (m *MemorySignalStore) setNX(key string, ttl time.Duration) bool {
+	if _, ok := m.get(key); ok {
+		return false
+	}
+	m.set(key, "1", ttl)
+	return true
+}
+
+func (m *MemorySignalStore) del(key string) {
+	delete(m.values, key)
+	delete(m.expires, key)
+}
+
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// MemoryCatalogStore is an in-process CatalogStore for tests and local tooling
+type MemoryCatalogStore struct {
+	mu          sync.Mutex
+	items       map[int64]models.Item
+	events      []models.Event
+	models      []models.Model
+	embeddings  map[int64]map[int64][]float64
+	nextEventID int64
+	nextModelID int64
+}
+
+// NewMemoryCatalogStore creates an in-memory catalog holding the given items
+func NewMemoryCatalogStore(items ...models.Item) *MemoryCatalogStore {
+	m := &MemoryCatalogStore{
+		items:      make(map[int64]models.Item),
+		embeddings: make(map[int64]map[int64][]float64),
+	}
+	for _, item := range items {
+		m.items[item.ID] = item
+	}
+	return m
+}
+
+// PutItem adds or replaces a catalog item
+func (m *MemoryCatalogStore) PutItem(item models.Item) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[item.ID] = item
+}
+
+// Events returns a copy of the stored event log
+func (m *MemoryCatalogStore) Events() []models.Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.Event(nil), m.events...)
+}
+
+// InsertEvent appends an event to the event log
+func (m *MemoryCatalogStore) InsertEvent(ctx context.Context, event *models.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextEventID++
+	event.ID = m.nextEventID
+	m.events = append(m.events, *event)
+	return nil
+}
+
+// InsertEvents inserts events and sets their IDs
+func (m *MemoryCatalogStore) InsertEvents(ctx context.Context, events []*models.Event) error {
+	for _, event := range events {
+		if err := m.InsertEvent(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanEvents calls fn for every event logged in [since, until), oldest first
+func (m *MemoryCatalogStore) ScanEvents(ctx context.Context, since, until time.Time, fn func(*models.Event) error) error {
+	var events []models.Event
+	for _, event := range m.Events() {
+		if !event.Timestamp.Before(since) && event.Timestamp.Before(until) {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.Before(events[j].Timestamp) })
+
+	for i := range events {
+		if err := fn(&events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetItem retrieves an item by ID, returning pgx.ErrNoRows like PostgresStore
+func (m *MemoryCatalogStore) GetItem(ctx context.Context, itemID int64) (*models.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.items[itemID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &item, nil
+}
+
+// GetItems retrieves multiple items by IDs
+func (m *MemoryCatalogStore) GetItems(ctx context.Context, itemIDs []int64) ([]models.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var items []models.Item
+	for _, itemID := range itemIDs {
+		if item, ok := m.items[itemID]; ok {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// GetItemsByCategory retrieves items by category
+func (m *MemoryCatalogStore) GetItemsByCategory(ctx context.Context, category string, limit int) ([]models.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var items []models.Item
+	for _, item := range m.items {
+		if item.Category == category {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// InsertModel inserts a new model metadata. A model without a status is
+// inserted as complete.
+func (m *MemoryCatalogStore) InsertModel(ctx context.Context, model *models.Model) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if model.Status == "" {
+		model.Status = models.ModelStatusComplete
+	}
+	m.nextModelID++
+	model.ID = m.nextModelID
+	model.CreatedAt = time.Now()
+	m.models = append(m.models, *model)
+	return nil
+}
+
+// CompleteModel marks a pending model version complete once its data is
+// written, returning pgx.ErrNoRows if the model does not exist
+func (m *MemoryCatalogStore) CompleteModel(ctx context.Context, modelID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.models {
+		if m.models[i].ID == modelID {
+			m.models[i].Status = models.ModelStatusComplete
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+// GetLatestModel retrieves the most recently inserted complete version of a
+// model whose data has not expired, returning pgx.ErrNoRows if there is none
+func (m *MemoryCatalogStore) GetLatestModel(ctx context.Context, modelName string) (*models.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for i := len(m.models) - 1; i >= 0; i-- {
+		if m.models[i].ModelName == modelName && servable(&m.models[i], now) {
+			model := m.models[i]
+			return &model, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+// GetActiveModel retrieves the active version of a model, returning
+// pgx.ErrNoRows if none has been activated
+func (m *MemoryCatalogStore) GetActiveModel(ctx context.Context, modelName string) (*models.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, model := range m.models {
+		if model.ModelName == modelName && model.Active {
+			return &model, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+// GetModel retrieves one version of a model, returning pgx.ErrNoRows if it
+// does not exist
+func (m *MemoryCatalogStore) GetModel(ctx context.Context, modelName, version string) (*models.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, model := range m.models {
+		if model.ModelName == modelName && model.Version == version {
+			return &model, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+// ListModels retrieves the versions of a model, or of all models when
+// modelName is empty, ordered by name and newest first
+func (m *MemoryCatalogStore) ListModels(ctx context.Context, modelName string) ([]models.Model, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []models.Model
+	for i := len(m.models) - 1; i >= 0; i-- {
+		if modelName == "" || m.models[i].ModelName == modelName {
+			list = append(list, m.models[i])
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].ModelName < list[j].ModelName })
+	return list, nil
+}
+
+// ActivateModel makes a model version the active one for its model name,
+// returning pgx.ErrNoRows if the model does not exist and
+// ErrModelNotServable if it is not complete or has expired
+func (m *MemoryCatalogStore) ActivateModel(ctx context.Context, modelID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	target := -1
+	for i := range m.models {
+		if m.models[i].ID == modelID {
+			target = i
+		}
+	}
+	if target < 0 {
+		return pgx.ErrNoRows
+	}
+
+	now := time.Now()
+	if !servable(&m.models[target], now) {
+		return ErrModelNotServable
+	}
+	for i := range m.models {
+		if m.models[i].ModelName == m.models[target].ModelName {
+			m.models[i].Active = false
+		}
+	}
+	m.models[target].Active = true
+	m.models[target].ActivatedAt = &now
+	return nil
+}
+
+// servable reports whether a model version is complete and its data has not
+// expired at now, matching the conditions PostgresStore checks in SQL
+func servable(model *models.Model, now time.Time) bool {
+	return model.Status == models.ModelStatusComplete && (model.ExpiresAt == nil || model.ExpiresAt.After(now))
+}
+
+// GetItemEmbeddings retrieves the item embeddings of a model
+func (m *MemoryCatalogStore) GetItemEmbeddings(ctx context.Context, modelID int64) (map[int64][]float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	embeddings := make(map[int64][]float64, len(m.embeddings[modelID]))
+	for itemID, embedding := range m.embeddings[modelID] {
+		embeddings[itemID] = embedding
+	}
+	return embeddings, nil
+}
+
+// SaveItemEmbeddings stores the item embeddings of a model, skipping items
+// missing from the catalog like PostgresStore
+func (m *MemoryCatalogStore) SaveItemEmbeddings(ctx context.Context, modelID int64, embeddings map[int64][]float64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := m.embeddings[modelID]
+	if saved == nil {
+		saved = make(map[int64][]float64, len(embeddings))
+		m.embeddings[modelID] = saved
+	}
+	var count int64
+	for itemID, embedding := range embeddings {
+		if _, ok := m.items[itemID]; ok {
+			saved[itemID] = append([]float64(nil), embedding...)
+			count++
+		}
+	}
+	return count, nil
+}
+
+var (
+	_ RecoSignalStore = (*MemorySignalStore)(nil)
+	_ CatalogStore    = (*MemoryCatalogStore)(nil)
+)
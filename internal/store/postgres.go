@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/yourusername/reco-engine/internal/models"
 	"github.com/yourusername/reco-engine/internal/util/config"
@@ -31,6 +32,7 @@ func NewPostgresStore(cfg config.PostgresConfig) (*PostgresStore, error) {
 
 	poolConfig.MaxConns = int32(cfg.MaxOpenConns)
 	poolConfig.MinConns = int32(cfg.MaxIdleConns)
+	poolConfig.ConnConfig.Tracer = pgTracer{}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -53,6 +55,11 @@ func NewPostgresStore(cfg config.PostgresConfig) (*PostgresStore, error) {
 	return &PostgresStore{pool: pool}, nil
 }
 
+// Ping checks that the database is reachable
+func (p *PostgresStore) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
 // Close closes the database connection pool
 func (p *PostgresStore) Close() {
 	p.pool.Close()
@@ -60,19 +67,69 @@ func (p *PostgresStore) Close() {
 
 // InsertEvent inserts an event into the database
 func (p *PostgresStore) InsertEvent(ctx context.Context, event *models.Event) error {
+	return p.InsertEvents(ctx, []*models.Event{event})
+}
+
+// InsertEvents inserts events in a single round trip and sets their IDs
+func (p *PostgresStore) InsertEvents(ctx context.Context, events []*models.Event) error {
 	query := `
-		INSERT INTO events (user_id, item_id, event_type, session_id, metadata, timestamp)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO events (event_id, user_id, item_id, event_type, session_id, metadata, timestamp)
+		VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6, $7)
 		RETURNING id
 	`
-	return p.pool.QueryRow(ctx, query,
-		event.UserID,
-		event.ItemID,
-		event.EventType,
-		event.SessionID,
-		event.Metadata,
-		event.Timestamp,
-	).Scan(&event.ID)
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		event := event
+		batch.Queue(query,
+			event.EventID,
+			event.UserID,
+			event.ItemID,
+			event.EventType,
+			event.SessionID,
+			event.Metadata,
+			event.Timestamp,
+		).QueryRow(func(row pgx.Row) error {
+			return row.Scan(&event.ID)
+		})
+	}
+	return p.pool.SendBatch(ctx, batch).Close()
+}
+
+// ScanEvents calls fn for every event logged in [since, until), oldest first.
+// Scanning stops at the first error returned by fn.
+func (p *PostgresStore) ScanEvents(ctx context.Context, since, until time.Time, fn func(*models.Event) error) error {
+	query := `
+		SELECT id, COALESCE(event_id, ''), COALESCE(user_id, 0), COALESCE(item_id, 0),
+		       event_type, COALESCE(session_id, ''), timestamp
+		FROM events
+		WHERE timestamp >= $1 AND timestamp < $2
+		ORDER BY timestamp, id
+	`
+	rows, err := p.pool.Query(ctx, query, since, until)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var event models.Event
+	for rows.Next() {
+		if err := rows.Scan(
+			&event.ID,
+			&event.EventID,
+			&event.UserID,
+			&event.ItemID,
+			&event.EventType,
+			&event.SessionID,
+			&event.Timestamp,
+		); err != nil {
+			return err
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
 
 // GetItem retrieves an item by ID
@@ -173,11 +230,15 @@ func (p *PostgresStore) GetItemsByCategory(ctx context.Context, category string,
 	return items, rows.Err()
 }
 
-// InsertModel inserts a new model metadata
+// InsertModel inserts a new model metadata. A model without a status is
+// inserted as complete.
 func (p *PostgresStore) InsertModel(ctx context.Context, model *models.Model) error {
+	if model.Status == "" {
+		model.Status = models.ModelStatusComplete
+	}
 	query := `
-		INSERT INTO models (model_name, version, model_type, metrics, config)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO models (model_name, version, model_type, metrics, config, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at
 	`
 	return p.pool.QueryRow(ctx, query,
@@ -186,5 +247,224 @@ func (p *PostgresStore) InsertModel(ctx context.Context, model *models.Model) er
 		model.ModelType,
 		model.Metrics,
 		model.Config,
+		model.Status,
+		model.ExpiresAt,
 	).Scan(&model.ID, &model.CreatedAt)
 }
+
+// CompleteModel marks a pending model version complete once its data is
+// written, returning pgx.ErrNoRows if the model does not exist
+func (p *PostgresStore) CompleteModel(ctx context.Context, modelID int64) error {
+	tag, err := p.pool.Exec(ctx, `UPDATE models SET status = $2 WHERE id = $1`, modelID, models.ModelStatusComplete)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// modelColumns are the models columns read by scanModel
+const modelColumns = `id, model_name, version, model_type, metrics, config, created_at, status, is_active, activated_at, expires_at`
+
+// scanModel scans a row of modelColumns
+func scanModel(row pgx.Row) (*models.Model, error) {
+	var model models.Model
+	var modelType *string
+	err := row.Scan(
+		&model.ID,
+		&model.ModelName,
+		&model.Version,
+		&modelType,
+		&model.Metrics,
+		&model.Config,
+		&model.CreatedAt,
+		&model.Status,
+		&model.Active,
+		&model.ActivatedAt,
+		&model.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if modelType != nil {
+		model.ModelType = *modelType
+	}
+	return &model, nil
+}
+
+// GetLatestModel retrieves the most recently created complete version of a
+// model whose data has not expired, returning pgx.ErrNoRows if there is none
+func (p *PostgresStore) GetLatestModel(ctx context.Context, modelName string) (*models.Model, error) {
+	query := `
+		SELECT ` + modelColumns + `
+		FROM models
+		WHERE model_name = $1 AND status = $2 AND (expires_at IS NULL OR expires_at > now())
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+	return scanModel(p.pool.QueryRow(ctx, query, modelName, models.ModelStatusComplete))
+}
+
+// GetActiveModel retrieves the active version of a model, returning
+// pgx.ErrNoRows if none has been activated
+func (p *PostgresStore) GetActiveModel(ctx context.Context, modelName string) (*models.Model, error) {
+	query := `
+		SELECT ` + modelColumns + `
+		FROM models
+		WHERE model_name = $1 AND is_active
+	`
+	return scanModel(p.pool.QueryRow(ctx, query, modelName))
+}
+
+// GetModel retrieves one version of a model, returning pgx.ErrNoRows if it
+// does not exist
+func (p *PostgresStore) GetModel(ctx context.Context, modelName, version string) (*models.Model, error) {
+	query := `
+		SELECT ` + modelColumns + `
+		FROM models
+		WHERE model_name = $1 AND version = $2
+	`
+	return scanModel(p.pool.QueryRow(ctx, query, modelName, version))
+}
+
+// ListModels retrieves the versions of a model, or of all models when
+// modelName is empty, ordered by name and newest first
+func (p *PostgresStore) ListModels(ctx context.Context, modelName string) ([]models.Model, error) {
+	query := `
+		SELECT ` + modelColumns + `
+		FROM models
+		WHERE $1 = '' OR model_name = $1
+		ORDER BY model_name, created_at DESC, id DESC
+	`
+	rows, err := p.pool.Query(ctx, query, modelName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.Model
+	for rows.Next() {
+		model, err := scanModel(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *model)
+	}
+
+	return list, rows.Err()
+}
+
+// ActivateModel makes a model version the active one for its model name,
+// deactivating the previous one in the same transaction. It returns
+// pgx.ErrNoRows if the model does not exist and ErrModelNotServable if it is
+// not complete or has expired.
+func (p *PostgresStore) ActivateModel(ctx context.Context, modelID int64) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var modelName string
+	err = tx.QueryRow(ctx, `SELECT model_name FROM models WHERE id = $1 FOR UPDATE`, modelID).Scan(&modelName)
+	if err != nil {
+		return err
+	}
+
+	// Deactivate first, as at most one version per name may be active
+	_, err = tx.Exec(ctx, `
+		UPDATE models SET is_active = false
+		WHERE model_name = $1 AND is_active AND id <> $2
+	`, modelName, modelID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate model: %w", err)
+	}
+
+	// Checked in the update so that a version expiring after the caller
+	// looked at it is still refused
+	tag, err := tx.Exec(ctx, `
+		UPDATE models SET is_active = true, activated_at = now()
+		WHERE id = $1 AND status = $2 AND (expires_at IS NULL OR expires_at > now())
+	`, modelID, models.ModelStatusComplete)
+	if err != nil {
+		return fmt.Errorf("failed to activate model: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrModelNotServable
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetItemEmbeddings retrieves the item embeddings of a model
+func (p *PostgresStore) GetItemEmbeddings(ctx context.Context, modelID int64) (map[int64][]float64, error) {
+	query := `
+		SELECT item_id, embedding
+		FROM item_embeddings
+		WHERE model_id = $1 AND embedding IS NOT NULL
+	`
+	rows, err := p.pool.Query(ctx, query, modelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	embeddings := make(map[int64][]float64)
+	for rows.Next() {
+		var itemID int64
+		var embedding []float64
+		if err := rows.Scan(&itemID, &embedding); err != nil {
+			return nil, err
+		}
+		embeddings[itemID] = embedding
+	}
+
+	return embeddings, rows.Err()
+}
+
+// SaveItemEmbeddings stores the item embeddings of a model in one transaction
+// and returns how many were saved. Items missing from the catalog are skipped.
+func (p *PostgresStore) SaveItemEmbeddings(ctx context.Context, modelID int64, embeddings map[int64][]float64) (int64, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		CREATE TEMP TABLE item_embeddings_load (item_id BIGINT, embedding FLOAT8[]) ON COMMIT DROP
+	`); err != nil {
+		return 0, fmt.Errorf("failed to create load table: %w", err)
+	}
+
+	rows := make([][]interface{}, 0, len(embeddings))
+	for itemID, embedding := range embeddings {
+		rows = append(rows, []interface{}{itemID, embedding})
+	}
+	if _, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"item_embeddings_load"},
+		[]string{"item_id", "embedding"},
+		pgx.CopyFromRows(rows),
+	); err != nil {
+		return 0, fmt.Errorf("failed to copy embeddings: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO item_embeddings (model_id, item_id, embedding)
+		SELECT $1, l.item_id, l.embedding
+		FROM item_embeddings_load l
+		JOIN items i ON i.id = l.item_id
+		ON CONFLICT (model_id, item_id)
+		DO UPDATE SET embedding = EXCLUDED.embedding, updated_at = now()
+	`, modelID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert embeddings: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -2,7 +2,11 @@ package store
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -14,6 +18,7 @@ import (
 // RedisStore handles Redis operations
 type RedisStore struct {
 	client *redis.Client
+	done   chan struct{}
 }
 
 // NewRedisStore creates a new Redis store
@@ -28,6 +33,8 @@ func NewRedisStore(cfg config.RedisConfig) (*RedisStore, error) {
 		ReadTimeout:  3 * time.Second,
 		WriteTimeout: 3 * time.Second,
 	})
+	client.AddHook(redisTracingHook{})
+	client.AddHook(redisMetricsHook{})
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -38,7 +45,11 @@ func NewRedisStore(cfg config.RedisConfig) (*RedisStore, error) {
 	}
 
 	logger.Info("Connected to Redis", zap.String("addr", cfg.Addr))
-	return &RedisStore{client: client}, nil
+
+	done := make(chan struct{})
+	go reportPoolStats(client, done)
+
+	return &RedisStore{client: client, done: done}, nil
 }
 
 // Client returns the Redis client
@@ -46,57 +57,171 @@ func (r *RedisStore) Client() *redis.Client {
 	return r.client
 }
 
+// Ping checks that Redis is reachable
+func (r *RedisStore) Ping(ctx context.Context) (err error) {
+	defer observeRedis("Ping", time.Now(), &err)
+
+	return r.client.Ping(ctx).Err()
+}
+
 // Close closes the Redis connection
 func (r *RedisStore) Close() error {
+	close(r.done)
 	return r.client.Close()
 }
 
-// AddRecentItem adds an item to user's recent items list
-func (r *RedisStore) AddRecentItem(ctx context.Context, userID, itemID int64, limit int) error {
-	key := fmt.Sprintf("user:recent:%d", userID)
-	pipe := r.client.Pipeline()
-	pipe.LPush(ctx, key, itemID)
-	pipe.LTrim(ctx, key, 0, int64(limit-1))
-	pipe.Expire(ctx, key, 24*time.Hour)
-	_, err := pipe.Exec(ctx)
-	return err
-}
-
 // GetRecentItems gets user's recent items
-func (r *RedisStore) GetRecentItems(ctx context.Context, userID int64, count int) ([]string, error) {
+func (r *RedisStore) GetRecentItems(ctx context.Context, userID int64, count int) (items []string, err error) {
+	defer observeRedis("GetRecentItems", time.Now(), &err)
+
 	key := fmt.Sprintf("user:recent:%d", userID)
 	return r.client.LRange(ctx, key, 0, int64(count-1)).Result()
 }
 
-// IncrPopularity increments item popularity score
-func (r *RedisStore) IncrPopularity(ctx context.Context, itemID int64, weight float64) error {
-	return r.client.ZIncrBy(ctx, "item:popularity", weight, fmt.Sprintf("%d", itemID)).Err()
+const (
+	popularityKey           = "item:popularity"
+	popularityCategoriesKey = "meta:popularity:categories"
+	popularityDecayedAtKey  = "meta:popularity:decayed_at"
+)
+
+func categoryPopularityKey(category string) string {
+	return fmt.Sprintf("%s:%s", popularityKey, category)
+}
+
+// Item-item relations kept by the processor, named by their key prefix.
+// Co-occurrences are symmetric; transitions count item -> next item only.
+const (
+	RelationCoView     = "co_view"
+	RelationCoCart     = "co_cart"
+	RelationCoPurchase = "co_purchase"
+	RelationTransition = "transition"
+)
+
+func relatedKey(relation string, itemID int64) string {
+	return fmt.Sprintf("%s:%d", relation, itemID)
+}
+
+// dedupKey is the key recording that an event ID was seen in a dedup scope
+func dedupKey(scope, eventID string) string {
+	return fmt.Sprintf("dedup:%s:%s", scope, eventID)
+}
+
+// KNNTTL is how long precomputed neighbour lists are kept
+const KNNTTL = 7 * 24 * time.Hour
+
+// knnKey is the key of an item's precomputed neighbours under an embedding
+// model. Keying by model lets serving switch between model versions.
+func knnKey(modelID, itemID int64) string {
+	return fmt.Sprintf("item:knn:%d:%d", modelID, itemID)
+}
+
+// relatedTTL is how long an item's relation is kept after its last update.
+// Purchases are much sparser than views, so co-purchases are kept longer.
+func relatedTTL(relation string) time.Duration {
+	if relation == RelationCoPurchase {
+		return 30 * 24 * time.Hour
+	}
+	return 7 * 24 * time.Hour
+}
+
+// GetPurchasedItemsSince gets items the user purchased since the given time
+func (r *RedisStore) GetPurchasedItemsSince(ctx context.Context, userID int64, since time.Time) (items []string, err error) {
+	defer observeRedis("GetPurchasedItemsSince", time.Now(), &err)
+
+	key := fmt.Sprintf("user:purchased:%d", userID)
+	return r.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: strconv.FormatInt(since.Unix(), 10),
+		Max: "+inf",
+	}).Result()
+}
+
+// GetRecentItemTimes gets user's most recently touched distinct items with
+// the time they were last touched, newest first
+func (r *RedisStore) GetRecentItemTimes(ctx context.Context, userID int64, count int) (items []redis.Z, err error) {
+	defer observeRedis("GetRecentItemTimes", time.Now(), &err)
+
+	key := fmt.Sprintf("user:recent_ts:%d", userID)
+	return r.client.ZRevRangeWithScores(ctx, key, 0, int64(count-1)).Result()
 }
 
 // GetPopularItems gets top popular items
-func (r *RedisStore) GetPopularItems(ctx context.Context, count int) ([]redis.Z, error) {
-	return r.client.ZRevRangeWithScores(ctx, "item:popularity", 0, int64(count-1)).Result()
+func (r *RedisStore) GetPopularItems(ctx context.Context, count int) (items []redis.Z, err error) {
+	defer observeRedis("GetPopularItems", time.Now(), &err)
+
+	return r.client.ZRevRangeWithScores(ctx, popularityKey, 0, int64(count-1)).Result()
 }
 
-// IncrCoView increments co-view count between two items
-func (r *RedisStore) IncrCoView(ctx context.Context, itemID1, itemID2 int64) error {
-	key := fmt.Sprintf("co_view:%d", itemID1)
-	pipe := r.client.Pipeline()
-	pipe.ZIncrBy(ctx, key, 1, fmt.Sprintf("%d", itemID2))
-	pipe.Expire(ctx, key, 7*24*time.Hour)
-	_, err := pipe.Exec(ctx)
+// GetCategoryPopularItems gets top popular items of a category
+func (r *RedisStore) GetCategoryPopularItems(ctx context.Context, category string, count int) (items []redis.Z, err error) {
+	defer observeRedis("GetCategoryPopularItems", time.Now(), &err)
+
+	return r.client.ZRevRangeWithScores(ctx, categoryPopularityKey(category), 0, int64(count-1)).Result()
+}
+
+// GetPopularityDecayedAt returns when popularity scores were last decayed,
+// or the zero time if they never were
+func (r *RedisStore) GetPopularityDecayedAt(ctx context.Context) (decayedAt time.Time, err error) {
+	defer observeRedis("GetPopularityDecayedAt", time.Now(), &err)
+
+	nanos, err := r.client.Get(ctx, popularityDecayedAtKey).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// DecayPopularity multiplies all global and per-category popularity scores by
+// factor, drops items whose score falls below minScore and records decayedAt
+func (r *RedisStore) DecayPopularity(ctx context.Context, factor, minScore float64, decayedAt time.Time) (err error) {
+	defer observeRedis("DecayPopularity", time.Now(), &err)
+
+	categories, err := r.client.SMembers(ctx, popularityCategoriesKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{popularityKey}
+	for _, category := range categories {
+		keys = append(keys, categoryPopularityKey(category))
+	}
+
+	pipe := r.client.TxPipeline()
+	for _, key := range keys {
+		pipe.ZUnionStore(ctx, key, &redis.ZStore{
+			Keys:    []string{key},
+			Weights: []float64{factor},
+		})
+		pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%g", minScore))
+	}
+	pipe.Set(ctx, popularityDecayedAtKey, decayedAt.UnixNano(), 0)
+	_, err = pipe.Exec(ctx)
 	return err
 }
 
-// GetCoViewItems gets items co-viewed with given item
-func (r *RedisStore) GetCoViewItems(ctx context.Context, itemID int64, count int) ([]redis.Z, error) {
-	key := fmt.Sprintf("co_view:%d", itemID)
-	return r.client.ZRevRangeWithScores(ctx, key, 0, int64(count-1)).Result()
+// AcquireLease takes a named lease for ttl and reports whether it was free.
+// It is used to let a single instance run periodic maintenance.
+func (r *RedisStore) AcquireLease(ctx context.Context, name string, ttl time.Duration) (acquired bool, err error) {
+	defer observeRedis("AcquireLease", time.Now(), &err)
+
+	return r.client.SetNX(ctx, fmt.Sprintf("lease:%s", name), 1, ttl).Result()
 }
 
-// SetItemKNN stores precomputed k-nearest neighbors for an item
-func (r *RedisStore) SetItemKNN(ctx context.Context, itemID int64, neighbors []int64) error {
-	key := fmt.Sprintf("item:knn:%d", itemID)
+// GetRelatedItems gets the top items of an item-item relation for the given item
+func (r *RedisStore) GetRelatedItems(ctx context.Context, relation string, itemID int64, count int) (items []redis.Z, err error) {
+	defer observeRedis("GetRelatedItems", time.Now(), &err)
+
+	return r.client.ZRevRangeWithScores(ctx, relatedKey(relation, itemID), 0, int64(count-1)).Result()
+}
+
+// SetItemKNN stores the precomputed k-nearest neighbors of an item under an
+// embedding model
+func (r *RedisStore) SetItemKNN(ctx context.Context, modelID, itemID int64, neighbors []int64) (err error) {
+	defer observeRedis("SetItemKNN", time.Now(), &err)
+
+	key := knnKey(modelID, itemID)
 	values := make([]interface{}, len(neighbors))
 	for i, n := range neighbors {
 		values[i] = n
@@ -107,25 +232,335 @@ func (r *RedisStore) SetItemKNN(ctx context.Context, itemID int64, neighbors []i
 	if len(values) > 0 {
 		pipe.RPush(ctx, key, values...)
 	}
-	pipe.Expire(ctx, key, 7*24*time.Hour)
-	_, err := pipe.Exec(ctx)
+	pipe.Expire(ctx, key, KNNTTL)
+	_, err = pipe.Exec(ctx)
 	return err
 }
 
-// GetItemKNN gets precomputed k-nearest neighbors
-func (r *RedisStore) GetItemKNN(ctx context.Context, itemID int64, count int) ([]string, error) {
-	key := fmt.Sprintf("item:knn:%d", itemID)
+// knnBatch is the number of items written per pipeline by SetItemKNNs
+const knnBatch = 1000
+
+// SetItemKNNs stores the precomputed k-nearest neighbors of many items under
+// an embedding model, replacing their lists in pipelines of knnBatch items
+func (r *RedisStore) SetItemKNNs(ctx context.Context, modelID int64, neighbors map[int64][]int64) (err error) {
+	defer observeRedis("SetItemKNNs", time.Now(), &err)
+
+	pipe := r.client.Pipeline()
+	queued := 0
+	for itemID, list := range neighbors {
+		key := knnKey(modelID, itemID)
+		pipe.Del(ctx, key)
+		if len(list) > 0 {
+			values := make([]interface{}, len(list))
+			for i, n := range list {
+				values[i] = n
+			}
+			pipe.RPush(ctx, key, values...)
+		}
+		pipe.Expire(ctx, key, KNNTTL)
+		if queued++; queued == knnBatch {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+			queued = 0
+		}
+	}
+	if queued > 0 {
+		_, err = pipe.Exec(ctx)
+	}
+	return err
+}
+
+// GetItemKNN gets the precomputed k-nearest neighbors of an item under an
+// embedding model
+func (r *RedisStore) GetItemKNN(ctx context.Context, modelID, itemID int64, count int) (items []string, err error) {
+	defer observeRedis("GetItemKNN", time.Now(), &err)
+
+	key := knnKey(modelID, itemID)
 	return r.client.LRange(ctx, key, 0, int64(count-1)).Result()
 }
 
+// MarkEventSeen records an event ID under the given dedup scope for ttl.
+// It returns false if the ID was already recorded within the window.
+func (r *RedisStore) MarkEventSeen(ctx context.Context, scope, eventID string, ttl time.Duration) (isNew bool, err error) {
+	defer observeRedis("MarkEventSeen", time.Now(), &err)
+
+	key := dedupKey(scope, eventID)
+	return r.client.SetNX(ctx, key, 1, ttl).Result()
+}
+
+// MarkEventsSeen records event IDs under the given dedup scope for ttl in one
+// round trip. It reports for each ID whether it is new; an ID repeated within
+// eventIDs is new only the first time.
+func (r *RedisStore) MarkEventsSeen(ctx context.Context, scope string, eventIDs []string, ttl time.Duration) (isNew []bool, err error) {
+	defer observeRedis("MarkEventsSeen", time.Now(), &err)
+
+	if len(eventIDs) == 0 {
+		return nil, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.BoolCmd, len(eventIDs))
+	for i, eventID := range eventIDs {
+		cmds[i] = pipe.SetNX(ctx, dedupKey(scope, eventID), 1, ttl)
+	}
+	if _, err = pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	isNew = make([]bool, len(eventIDs))
+	for i, cmd := range cmds {
+		isNew[i] = cmd.Val()
+	}
+	return isNew, nil
+}
+
+// ForgetEvent removes an event ID from the given dedup scope
+func (r *RedisStore) ForgetEvent(ctx context.Context, scope, eventID string) (err error) {
+	defer observeRedis("ForgetEvent", time.Now(), &err)
+
+	key := dedupKey(scope, eventID)
+	return r.client.Del(ctx, key).Err()
+}
+
+// userFactorsBatch is the number of users written per pipeline by SetUserFactors
+const userFactorsBatch = 1000
+
+func userFactorsKey(modelID, userID int64) string {
+	return fmt.Sprintf("user:factors:%d:%d", modelID, userID)
+}
+
+// encodeFactors packs factors as little-endian float32s
+func encodeFactors(factors []float64) string {
+	buf := make([]byte, 4*len(factors))
+	for i, f := range factors {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(f)))
+	}
+	return string(buf)
+}
+
+func decodeFactors(value string) ([]float64, error) {
+	if len(value)%4 != 0 {
+		return nil, fmt.Errorf("invalid factors length %d", len(value))
+	}
+	factors := make([]float64, len(value)/4)
+	for i := range factors {
+		factors[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32([]byte(value[4*i : 4*i+4]))))
+	}
+	return factors, nil
+}
+
+// SetUserFactors stores the collaborative filtering factors of a model's
+// users for ttl
+func (r *RedisStore) SetUserFactors(ctx context.Context, modelID int64, factors map[int64][]float64, ttl time.Duration) (err error) {
+	defer observeRedis("SetUserFactors", time.Now(), &err)
+
+	pipe := r.client.Pipeline()
+	queued := 0
+	for userID, f := range factors {
+		pipe.Set(ctx, userFactorsKey(modelID, userID), encodeFactors(f), ttl)
+		if queued++; queued == userFactorsBatch {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+			queued = 0
+		}
+	}
+	if queued > 0 {
+		_, err = pipe.Exec(ctx)
+	}
+	return err
+}
+
+// GetUserFactors gets a user's factors of a model, returning redis.Nil if the
+// user has none
+func (r *RedisStore) GetUserFactors(ctx context.Context, modelID, userID int64) (factors []float64, err error) {
+	defer observeRedis("GetUserFactors", time.Now(), &err)
+
+	value, err := r.client.Get(ctx, userFactorsKey(modelID, userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeFactors(value)
+}
+
 // CacheRecommendations caches recommendations for a user
-func (r *RedisStore) CacheRecommendations(ctx context.Context, userID int64, data string, ttl time.Duration) error {
+func (r *RedisStore) CacheRecommendations(ctx context.Context, userID int64, data string, ttl time.Duration) (err error) {
+	defer observeRedis("CacheRecommendations", time.Now(), &err)
+
 	key := fmt.Sprintf("cache:reco:%d", userID)
 	return r.client.Set(ctx, key, data, ttl).Err()
 }
 
 // GetCachedRecommendations gets cached recommendations
-func (r *RedisStore) GetCachedRecommendations(ctx context.Context, userID int64) (string, error) {
+func (r *RedisStore) GetCachedRecommendations(ctx context.Context, userID int64) (data string, err error) {
+	defer observeRedis("GetCachedRecommendations", time.Now(), &err)
+
 	key := fmt.Sprintf("cache:reco:%d", userID)
 	return r.client.Get(ctx, key).Result()
 }
+
+// GetRecentEventsForUsers gets the recent items of several users, newest
+// first, with the session and time they were touched in. It is written by
+// ApplySignalBatch only; malformed entries are skipped.
+func (r *RedisStore) GetRecentEventsForUsers(ctx context.Context, userIDs []int64, count int) (events map[int64][]RecentItem, err error) {
+	defer observeRedis("GetRecentEventsForUsers", time.Now(), &err)
+
+	if len(userIDs) == 0 {
+		return map[int64][]RecentItem{}, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(userIDs))
+	for i, userID := range userIDs {
+		cmds[i] = pipe.LRange(ctx, fmt.Sprintf("user:recent_events:%d", userID), 0, int64(count-1))
+	}
+	if _, err = pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	events = make(map[int64][]RecentItem, len(userIDs))
+	for i, userID := range userIDs {
+		for _, value := range cmds[i].Val() {
+			if item, parseErr := parseRecentEvent(value); parseErr == nil {
+				events[userID] = append(events[userID], item)
+			}
+		}
+	}
+	return events, nil
+}
+
+// ApplySignalBatch writes all updates of the batch in a single MULTI/EXEC
+// transaction, so a failed batch can be retried without double counting. The
+// batch's seen event IDs are set in the same transaction; if any of them is
+// already set, or is set before the transaction runs, nothing is written and
+// ErrEventsSeen is returned.
+func (r *RedisStore) ApplySignalBatch(ctx context.Context, batch *SignalBatch) (err error) {
+	defer observeRedis("ApplySignalBatch", time.Now(), &err)
+
+	if batch.Empty() {
+		return nil
+	}
+	if len(batch.Seen) == 0 {
+		_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			queueSignalBatch(ctx, pipe, batch)
+			return nil
+		})
+		return err
+	}
+
+	keys := make([]string, len(batch.Seen))
+	for i, eventID := range batch.Seen {
+		keys[i] = dedupKey(batch.SeenScope, eventID)
+	}
+	err = r.client.Watch(ctx, func(tx *redis.Tx) error {
+		n, err := tx.Exists(ctx, keys...).Result()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrEventsSeen
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			queueSignalBatch(ctx, pipe, batch)
+			for _, key := range keys {
+				pipe.Set(ctx, key, 1, batch.SeenTTL)
+			}
+			return nil
+		})
+		return err
+	}, keys...)
+	if errors.Is(err, redis.TxFailedErr) {
+		return ErrEventsSeen
+	}
+	return err
+}
+
+// queueSignalBatch queues the updates of the batch on pipe
+func queueSignalBatch(ctx context.Context, pipe redis.Pipeliner, batch *SignalBatch) {
+	for userID, recent := range batch.RecentItems {
+		key := fmt.Sprintf("user:recent:%d", userID)
+		tsKey := fmt.Sprintf("user:recent_ts:%d", userID)
+		eventsKey := fmt.Sprintf("user:recent_events:%d", userID)
+
+		values := make([]interface{}, len(recent))
+		events := make([]interface{}, len(recent))
+		touched := make(map[int64]time.Time, len(recent))
+		for i, item := range recent {
+			values[i] = item.ItemID
+			events[i] = formatRecentEvent(item)
+			touched[item.ItemID] = item.TouchedAt
+		}
+		members := make([]*redis.Z, 0, len(touched))
+		for itemID, touchedAt := range touched {
+			members = append(members, &redis.Z{Score: float64(touchedAt.UnixMilli()), Member: itemID})
+		}
+
+		pipe.LPush(ctx, key, values...)
+		pipe.LTrim(ctx, key, 0, int64(batch.RecentLimit-1))
+		pipe.Expire(ctx, key, 24*time.Hour)
+		pipe.ZAdd(ctx, tsKey, members...)
+		pipe.ZRemRangeByRank(ctx, tsKey, 0, int64(-batch.RecentLimit-1))
+		pipe.Expire(ctx, tsKey, 24*time.Hour)
+		pipe.LPush(ctx, eventsKey, events...)
+		pipe.LTrim(ctx, eventsKey, 0, int64(batch.RecentLimit-1))
+		pipe.Expire(ctx, eventsKey, 24*time.Hour)
+	}
+
+	for itemID, weight := range batch.Popularity {
+		pipe.ZIncrBy(ctx, popularityKey, weight, strconv.FormatInt(itemID, 10))
+	}
+
+	for category, items := range batch.CategoryPopularity {
+		for itemID, weight := range items {
+			pipe.ZIncrBy(ctx, categoryPopularityKey(category), weight, strconv.FormatInt(itemID, 10))
+		}
+		pipe.SAdd(ctx, popularityCategoriesKey, category)
+	}
+
+	for userID, purchases := range batch.Purchases {
+		key := fmt.Sprintf("user:purchased:%d", userID)
+		members := make([]*redis.Z, len(purchases))
+		for i, purchase := range purchases {
+			members[i] = &redis.Z{Score: float64(purchase.PurchasedAt.Unix()), Member: purchase.ItemID}
+		}
+		pipe.ZAdd(ctx, key, members...)
+		pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%d", time.Now().Add(-batch.PurchaseRetention).Unix()))
+		pipe.Expire(ctx, key, batch.PurchaseRetention)
+	}
+
+	for relation, related := range batch.Related {
+		for itemID, items := range related {
+			key := relatedKey(relation, itemID)
+			for otherID, weight := range items {
+				pipe.ZIncrBy(ctx, key, weight, strconv.FormatInt(otherID, 10))
+			}
+			pipe.Expire(ctx, key, relatedTTL(relation))
+		}
+	}
+}
+
+// EventsSeen reports for each event ID whether it is recorded under the given
+// dedup scope, in one round trip
+func (r *RedisStore) EventsSeen(ctx context.Context, scope string, eventIDs []string) (seen []bool, err error) {
+	defer observeRedis("EventsSeen", time.Now(), &err)
+
+	if len(eventIDs) == 0 {
+		return nil, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(eventIDs))
+	for i, eventID := range eventIDs {
+		cmds[i] = pipe.Exists(ctx, dedupKey(scope, eventID))
+	}
+	if _, err = pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	seen = make([]bool, len(eventIDs))
+	for i, cmd := range cmds {
+		seen[i] = cmd.Val() > 0
+	}
+	return seen, nil
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+)
+
+// poolStatsInterval is how often connection pool stats are exported
+const poolStatsInterval = 15 * time.Second
+
+// Redis operation and command statuses
+const (
+	redisStatusOK    = "ok"
+	redisStatusMiss  = "miss"
+	redisStatusError = "error"
+)
+
+func redisStatus(err error) string {
+	switch {
+	case err == nil:
+		return redisStatusOK
+	case errors.Is(err, redis.Nil):
+		return redisStatusMiss
+	default:
+		return redisStatusError
+	}
+}
+
+// observeRedis records the outcome and latency of a RedisStore method. It is
+// deferred at the top of each method with a pointer to its named error.
+func observeRedis(operation string, start time.Time, err *error) {
+	metrics.RedisLatency.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	metrics.RedisOperations.WithLabelValues(operation, redisStatus(*err)).Inc()
+}
+
+// redisMetricsHook counts every command sent to Redis, so that pipelined
+// methods are broken down per command
+type redisMetricsHook struct{}
+
+func (redisMetricsHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (redisMetricsHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	metrics.RedisCommands.WithLabelValues(cmd.Name(), redisStatus(cmd.Err())).Inc()
+	return nil
+}
+
+func (redisMetricsHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (redisMetricsHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		metrics.RedisCommands.WithLabelValues(cmd.Name(), redisStatus(cmd.Err())).Inc()
+	}
+	return nil
+}
+
+// reportPoolStats exports the client's connection pool stats until done is closed
+func reportPoolStats(client *redis.Client, done <-chan struct{}) {
+	ticker := time.NewTicker(poolStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		recordPoolStats(client.PoolStats())
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func recordPoolStats(stats *redis.PoolStats) {
+	metrics.RedisPoolStats.WithLabelValues("hits").Set(float64(stats.Hits))
+	metrics.RedisPoolStats.WithLabelValues("misses").Set(float64(stats.Misses))
+	metrics.RedisPoolStats.WithLabelValues("timeouts").Set(float64(stats.Timeouts))
+	metrics.RedisPoolStats.WithLabelValues("total_conns").Set(float64(stats.TotalConns))
+	metrics.RedisPoolStats.WithLabelValues("idle_conns").Set(float64(stats.IdleConns))
+	metrics.RedisPoolStats.WithLabelValues("stale_conns").Set(float64(stats.StaleConns))
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/util/config"
+	"github.com/yourusername/reco-engine/internal/util/metrics"
+)
+
+func TestRedisStoreMetrics(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisStore, err := NewRedisStore(config.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisStore.Close() })
+
+	ops := metrics.RedisOperations.WithLabelValues("ApplySignalBatch", redisStatusOK)
+	zincrby := metrics.RedisCommands.WithLabelValues("zincrby", redisStatusOK)
+	expire := metrics.RedisCommands.WithLabelValues("expire", redisStatusOK)
+	opsBefore, zincrbyBefore, expireBefore := testutil.ToFloat64(ops), testutil.ToFloat64(zincrby), testutil.ToFloat64(expire)
+
+	for _, pair := range [][2]int64{{1, 2}, {2, 1}} {
+		batch := NewSignalBatch(0, 0)
+		batch.AddRelated(RelationCoView, pair[0], pair[1], 1)
+		require.NoError(t, redisStore.ApplySignalBatch(ctx, batch))
+	}
+
+	assert.Equal(t, opsBefore+2, testutil.ToFloat64(ops))
+	assert.Equal(t, zincrbyBefore+2, testutil.ToFloat64(zincrby))
+	assert.Equal(t, expireBefore+2, testutil.ToFloat64(expire))
+
+	// A cache miss is not an error
+	misses := metrics.RedisOperations.WithLabelValues("GetCachedRecommendations", redisStatusMiss)
+	missesBefore := testutil.ToFloat64(misses)
+	_, err = redisStore.GetCachedRecommendations(ctx, 42)
+	assert.ErrorIs(t, err, redis.Nil)
+	assert.Equal(t, missesBefore+1, testutil.ToFloat64(misses))
+
+	recordPoolStats(redisStore.client.PoolStats())
+	assert.GreaterOrEqual(t, testutil.ToFloat64(metrics.RedisPoolStats.WithLabelValues("total_conns")), 1.0)
+}
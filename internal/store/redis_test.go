@@ -0,0 +1,36 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/reco-engine/internal/util/config"
+)
+
+func TestSetItemKNNs_WritesEveryChunk(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisStore, err := NewRedisStore(config.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisStore.Close() })
+
+	// A stale list is replaced rather than appended to
+	require.NoError(t, redisStore.SetItemKNN(ctx, 1, 0, []int64{7, 8, 9}))
+
+	neighbors := make(map[int64][]int64, knnBatch+1)
+	for itemID := int64(0); itemID <= knnBatch; itemID++ {
+		neighbors[itemID] = []int64{itemID + 1, itemID + 2}
+	}
+	require.NoError(t, redisStore.SetItemKNNs(ctx, 1, neighbors))
+
+	for _, itemID := range []int64{0, knnBatch} {
+		list, err := redisStore.GetItemKNN(ctx, 1, itemID, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{formatID(itemID + 1), formatID(itemID + 2)}, list)
+		assert.Equal(t, KNNTTL, mr.TTL(knnKey(1, itemID)))
+	}
+	assert.Len(t, mr.Keys(), knnBatch+1)
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/yourusername/reco-engine/internal/models"
+)
+
+// RecoSignalStore holds the real-time recommendation signals written by the
+// processor and read by the API. RedisStore is the production implementation
+// and MemorySignalStore the in-process one used in tests.
+type RecoSignalStore interface {
+	GetRecentItems(ctx context.Context, userID int64, count int) ([]string, error)
+	GetRecentItemTimes(ctx context.Context, userID int64, count int) ([]redis.Z, error)
+
+	GetPurchasedItemsSince(ctx context.Context, userID int64, since time.Time) ([]string, error)
+
+	GetPopularItems(ctx context.Context, count int) ([]redis.Z, error)
+	GetCategoryPopularItems(ctx context.Context, category string, count int) ([]redis.Z, error)
+	GetPopularityDecayedAt(ctx context.Context) (time.Time, error)
+	DecayPopularity(ctx context.Context, factor, minScore float64, decayedAt time.Time) error
+
+	GetRelatedItems(ctx context.Context, relation string, itemID int64, count int) ([]redis.Z, error)
+
+	SetItemKNN(ctx context.Context, modelID, itemID int64, neighbors []int64) error
+	SetItemKNNs(ctx context.Context, modelID int64, neighbors map[int64][]int64) error
+	GetItemKNN(ctx context.Context, modelID, itemID int64, count int) ([]string, error)
+
+	SetUserFactors(ctx context.Context, modelID int64, factors map[int64][]float64, ttl time.Duration) error
+	GetUserFactors(ctx context.Context, modelID, userID int64) ([]float64, error)
+
+	GetRecentEventsForUsers(ctx context.Context, userIDs []int64, count int) (map[int64][]RecentItem, error)
+	ApplySignalBatch(ctx context.Context, batch *SignalBatch) error
+
+	MarkEventSeen(ctx context.Context, scope, eventID string, ttl time.Duration) (bool, error)
+	MarkEventsSeen(ctx context.Context, scope string, eventIDs []string, ttl time.Duration) ([]bool, error)
+	EventsSeen(ctx context.Context, scope string, eventIDs []string) ([]bool, error)
+	ForgetEvent(ctx context.Context, scope, eventID string) error
+	AcquireLease(ctx context.Context, name string, ttl time.Duration) (bool, error)
+
+	CacheRecommendations(ctx context.Context, userID int64, data string, ttl time.Duration) error
+	GetCachedRecommendations(ctx context.Context, userID int64) (string, error)
+}
+
+// ErrModelNotServable is returned by ActivateModel for a model version that is
+// not complete or whose data has expired. Nothing is changed.
+var ErrModelNotServable = errors.New("model version is not complete or has expired")
+
+// CatalogStore holds the item catalog, the raw event log and model metadata.
+// PostgresStore is the production implementation and MemoryCatalogStore the
+// in-process one used in tests.
+type CatalogStore interface {
+	InsertEvent(ctx context.Context, event *models.Event) error
+	InsertEvents(ctx context.Context, events []*models.Event) error
+	ScanEvents(ctx context.Context, since, until time.Time, fn func(*models.Event) error) error
+	GetItem(ctx context.Context, itemID int64) (*models.Item, error)
+	GetItems(ctx context.Context, itemIDs []int64) ([]models.Item, error)
+	GetItemsByCategory(ctx context.Context, category string, limit int) ([]models.Item, error)
+	InsertModel(ctx context.Context, model *models.Model) error
+	CompleteModel(ctx context.Context, modelID int64) error
+	GetLatestModel(ctx context.Context, modelName string) (*models.Model, error)
+	GetActiveModel(ctx context.Context, modelName string) (*models.Model, error)
+	GetModel(ctx context.Context, modelName, version string) (*models.Model, error)
+	ListModels(ctx context.Context, modelName string) ([]models.Model, error)
+	ActivateModel(ctx context.Context, modelID int64) error
+	GetItemEmbeddings(ctx context.Context, modelID int64) (map[int64][]float64, error)
+	SaveItemEmbeddings(ctx context.Context, modelID int64, embeddings map[int64][]float64) (int64, error)
+}
+
+var (
+	_ RecoSignalStore = (*RedisStore)(nil)
+	_ CatalogStore    = (*PostgresStore)(nil)
+)
@@ -0,0 +1,37 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Ping checks that at least one broker is reachable and serves metadata for topic
+func Ping(ctx context.Context, brokers []string, topic string) error {
+	if len(brokers) == 0 {
+		return errors.New("no Kafka brokers configured")
+	}
+
+	var lastErr error
+	for _, broker := range brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to dial %s: %w", broker, err)
+			continue
+		}
+
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		_, err = conn.ReadPartitions(topic)
+		conn.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to read partitions of %s from %s: %w", topic, broker, err)
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
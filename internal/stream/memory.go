@@ -0,0 +1,131 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrClosed is returned by a closed MemoryTopic
+var ErrClosed = errors.New("stream: topic closed")
+
+// MemoryTopic is an in-process single-partition topic with one consumer
+// group. It implements both EventPublisher and EventConsumer so that ingest
+// and processor can be wired together in tests without Kafka.
+type MemoryTopic struct {
+	name string
+
+	mu        sync.Mutex
+	messages  []kafka.Message
+	next      int
+	committed int64
+	closed    bool
+	notify    chan struct{}
+}
+
+// NewMemoryTopic creates an empty in-memory topic
+func NewMemoryTopic(name string) *MemoryTopic {
+	return &MemoryTopic{
+		name:   name,
+		notify: make(chan struct{}),
+	}
+}
+
+// WriteMessages appends messages to the topic, assigning offsets
+func (t *MemoryTopic) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return ErrClosed
+	}
+
+	for _, msg := range msgs {
+		msg.Topic = t.name
+		msg.Partition = 0
+		msg.Offset = int64(len(t.messages))
+		if msg.Time.IsZero() {
+			msg.Time = time.Now()
+		}
+		t.messages = append(t.messages, msg)
+	}
+
+	// Wake up blocked consumers
+	close(t.notify)
+	t.notify = make(chan struct{})
+	return nil
+}
+
+// FetchMessage returns the next message, blocking until one is available
+func (t *MemoryTopic) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	for {
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			return kafka.Message{}, ErrClosed
+		}
+		if t.next < len(t.messages) {
+			msg := t.messages[t.next]
+			msg.HighWaterMark = int64(len(t.messages))
+			t.next++
+			t.mu.Unlock()
+			return msg, nil
+		}
+		notify := t.notify
+		t.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+// CommitMessages records the highest committed offset
+func (t *MemoryTopic) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, msg := range msgs {
+		if msg.Offset+1 > t.committed {
+			t.committed = msg.Offset + 1
+		}
+	}
+	return nil
+}
+
+// Committed returns the offset of the next message to be committed, i.e.
+// the number of messages processed when all are committed in order
+func (t *MemoryTopic) Committed() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.committed
+}
+
+// Messages returns a copy of all messages written to the topic
+func (t *MemoryTopic) Messages() []kafka.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]kafka.Message(nil), t.messages...)
+}
+
+// Close closes the topic; blocked consumers return ErrClosed
+func (t *MemoryTopic) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.closed {
+		t.closed = true
+		close(t.notify)
+	}
+	return nil
+}
+
+var (
+	_ EventPublisher = (*MemoryTopic)(nil)
+	_ EventConsumer  = (*MemoryTopic)(nil)
+)
@@ -0,0 +1,26 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// EventPublisher publishes messages to Kafka. *kafka.Writer implements it.
+type EventPublisher interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// EventConsumer consumes messages as a member of a consumer group.
+// *kafka.Reader implements it.
+type EventConsumer interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+var (
+	_ EventPublisher = (*kafka.Writer)(nil)
+	_ EventConsumer  = (*kafka.Reader)(nil)
+)
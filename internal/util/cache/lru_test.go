@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[int64, string](2, 0)
+	c.Set(1, "a")
+	c.Set(2, "b")
+
+	// Touch 1 so that 2 becomes the eviction candidate
+	_, ok := c.Get(1)
+	assert.True(t, ok)
+
+	c.Set(3, "c")
+	_, ok = c.Get(2)
+	assert.False(t, ok)
+
+	v, ok := c.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "a", v)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRU_Expires(t *testing.T) {
+	now := time.Now()
+	c := NewLRU[string, int](10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("k", 1)
+	v, ok := c.Get("k")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.Get("k")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
@@ -2,9 +2,11 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/spf13/viper"
+	"github.com/yourusername/reco-engine/internal/models"
 )
 
 // Config holds all configuration
@@ -16,6 +18,7 @@ type Config struct {
 	Processing     ProcessingConfig     `mapstructure:"processing"`
 	Recommendation RecommendationConfig `mapstructure:"recommendation"`
 	EventWeights   EventWeightsConfig   `mapstructure:"event_weights"`
+	Experiments    []ExperimentConfig   `mapstructure:"experiments"`
 	Observability  ObservabilityConfig  `mapstructure:"observability"`
 }
 
@@ -25,8 +28,10 @@ type ServerConfig struct {
 }
 
 type APIServerConfig struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
+	Host       string `mapstructure:"host"`
+	Port       int    `mapstructure:"port"`
+	AdminPort  int    `mapstructure:"admin_port"`
+	AdminToken string `mapstructure:"admin_token"`
 }
 
 type KafkaConfig struct {
@@ -36,7 +41,8 @@ type KafkaConfig struct {
 }
 
 type TopicConfig struct {
-	Events string `mapstructure:"events"`
+	Events     string `mapstructure:"events"`
+	DeadLetter string `mapstructure:"dead_letter"`
 }
 
 type RedisConfig struct {
@@ -64,18 +70,121 @@ func (p PostgresConfig) DSN() string {
 }
 
 type ProcessingConfig struct {
-	BatchSize        int           `mapstructure:"batch_size"`
-	FlushInterval    time.Duration `mapstructure:"flush_interval"`
-	RecentItemsLimit int           `mapstructure:"recent_items_limit"`
-	CoviewWindow     int           `mapstructure:"coview_window"`
+	BatchSize         int           `mapstructure:"batch_size"`
+	FlushInterval     time.Duration `mapstructure:"flush_interval"`
+	RecentItemsLimit  int           `mapstructure:"recent_items_limit"`
+	CoviewWindow      int           `mapstructure:"coview_window"`
+	CoviewMaxGap      time.Duration `mapstructure:"coview_max_gap"`
+	CoviewSameSession bool          `mapstructure:"coview_same_session"`
+	CoviewDecay       float64       `mapstructure:"coview_decay"`
+	DedupWindow       time.Duration `mapstructure:"dedup_window"`
+	Retry             RetryConfig   `mapstructure:"retry"`
+	CategoryCacheSize int           `mapstructure:"category_cache_size"`
+	CategoryCacheTTL  time.Duration `mapstructure:"category_cache_ttl"`
+	PurchaseHistory   time.Duration `mapstructure:"purchase_history"`
+	Workers           int           `mapstructure:"workers"`
+}
+
+type RetryConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// Backoff returns the delay before the given retry attempt (1-based),
+// doubling from InitialBackoff and capped at MaxBackoff
+func (r RetryConfig) Backoff(attempt int) time.Duration {
+	backoff := r.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if r.MaxBackoff > 0 && backoff >= r.MaxBackoff {
+			break
+		}
+	}
+	if r.MaxBackoff > 0 && backoff > r.MaxBackoff {
+		backoff = r.MaxBackoff
+	}
+	return backoff
+}
+
+// CoviewEvents returns how many of a user's events, the current one
+// included, are paired as co-views. It is at least 1, as the last event is
+// also needed for transitions.
+func (p ProcessingConfig) CoviewEvents() int {
+	if p.CoviewWindow < 1 {
+		return 1
+	}
+	return p.CoviewWindow
+}
+
+// CoviewWeight returns the weight of a co-view between two events distance
+// positions apart in a user's sequence (1 for consecutive events). Each step
+// further multiplies the weight by CoviewDecay; without a decay in (0, 1)
+// every pair in the window counts 1.
+func (p ProcessingConfig) CoviewWeight(distance int) float64 {
+	if p.CoviewDecay <= 0 || p.CoviewDecay >= 1 {
+		return 1
+	}
+	return math.Pow(p.CoviewDecay, float64(distance-1))
+}
+
+// DedupTTL returns how long event IDs are remembered for deduplication
+func (p ProcessingConfig) DedupTTL() time.Duration {
+	if p.DedupWindow <= 0 {
+		return 24 * time.Hour
+	}
+	return p.DedupWindow
 }
 
 type RecommendationConfig struct {
-	DefaultCount    int           `mapstructure:"default_count"`
-	MaxCount        int           `mapstructure:"max_count"`
-	CacheTTL        time.Duration `mapstructure:"cache_ttl"`
-	PopularityDecay float64       `mapstructure:"popularity_decay"`
-	Weights         WeightsConfig `mapstructure:"weights"`
+	DefaultCount            int           `mapstructure:"default_count"`
+	MaxCount                int           `mapstructure:"max_count"`
+	CacheTTL                time.Duration `mapstructure:"cache_ttl"`
+	PopularityDecay         float64       `mapstructure:"popularity_decay"`
+	PopularityHalfLife      time.Duration `mapstructure:"popularity_half_life"`
+	PopularityDecayInterval time.Duration `mapstructure:"popularity_decay_interval"`
+	Weights                 WeightsConfig `mapstructure:"weights"`
+	Filters                 FilterConfig  `mapstructure:"filters"`
+	ItemCacheSize           int           `mapstructure:"item_cache_size"`
+	ItemCacheTTL            time.Duration `mapstructure:"item_cache_ttl"`
+	RecencyHalfLife         time.Duration `mapstructure:"recency_half_life"`
+	ANN                     ANNConfig     `mapstructure:"ann"`
+	CF                      CFConfig      `mapstructure:"cf"`
+}
+
+// ANNConfig controls the in-process nearest neighbour index that answers
+// embedding candidates
+type ANNConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	ModelName       string        `mapstructure:"model_name"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	M               int           `mapstructure:"m"`
+	EfConstruction  int           `mapstructure:"ef_construction"`
+	EfSearch        int           `mapstructure:"ef_search"`
+}
+
+// CFConfig controls the collaborative filtering candidate source, which
+// scores items by the dot product of user and item factors
+type CFConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	ModelName       string        `mapstructure:"model_name"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// PopularityDecayFactor returns the multiplier for popularity scores after
+// elapsed time. PopularityHalfLife takes precedence; otherwise PopularityDecay
+// is applied once per PopularityDecayInterval. It returns 1 when decay is disabled.
+func (r RecommendationConfig) PopularityDecayFactor(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 1
+	}
+	if r.PopularityHalfLife > 0 {
+		return math.Pow(0.5, float64(elapsed)/float64(r.PopularityHalfLife))
+	}
+	if r.PopularityDecay > 0 && r.PopularityDecay < 1 && r.PopularityDecayInterval > 0 {
+		return math.Pow(r.PopularityDecay, float64(elapsed)/float64(r.PopularityDecayInterval))
+	}
+	return 1
 }
 
 type WeightsConfig struct {
@@ -83,6 +192,32 @@ type WeightsConfig struct {
 	Embedding  float64 `mapstructure:"embedding"`
 	Popularity float64 `mapstructure:"popularity"`
 	Recency    float64 `mapstructure:"recency"`
+	Transition float64 `mapstructure:"transition"`
+	CF         float64 `mapstructure:"cf"`
+}
+
+type FilterConfig struct {
+	ExcludeOutOfStock    bool `mapstructure:"exclude_out_of_stock"`
+	ExcludePurchasedDays int  `mapstructure:"exclude_purchased_days"`
+}
+
+// ExperimentConfig defines an A/B experiment whose variants override how
+// recommendations are generated
+type ExperimentConfig struct {
+	Name     string          `mapstructure:"name"`
+	Enabled  bool            `mapstructure:"enabled"`
+	Variants []VariantConfig `mapstructure:"variants"`
+}
+
+// VariantConfig is one arm of an experiment. Allocation is the relative share
+// of users; unset overrides keep the global recommendation settings.
+type VariantConfig struct {
+	Name           string         `mapstructure:"name"`
+	Allocation     int            `mapstructure:"allocation"`
+	Weights        *WeightsConfig `mapstructure:"weights"`
+	Sources        []string       `mapstructure:"sources"`
+	SeedCount      int            `mapstructure:"seed_count"`
+	NeighbourLimit int            `mapstructure:"neighbour_limit"`
 }
 
 type EventWeightsConfig struct {
@@ -92,10 +227,27 @@ type EventWeightsConfig struct {
 	Purchase float64 `mapstructure:"PURCHASE"`
 }
 
+// Weight returns the weight of an event type, 1 for unknown types
+func (w EventWeightsConfig) Weight(eventType string) float64 {
+	switch eventType {
+	case models.EventTypeView:
+		return w.View
+	case models.EventTypeClick:
+		return w.Click
+	case models.EventTypeCart:
+		return w.Cart
+	case models.EventTypePurchase:
+		return w.Purchase
+	default:
+		return 1.0
+	}
+}
+
 type ObservabilityConfig struct {
 	Metrics MetricsConfig `mapstructure:"metrics"`
 	Tracing TracingConfig `mapstructure:"tracing"`
 	Logging LoggingConfig `mapstructure:"logging"`
+	Health  HealthConfig  `mapstructure:"health"`
 }
 
 type MetricsConfig struct {
@@ -104,8 +256,14 @@ type MetricsConfig struct {
 }
 
 type TracingConfig struct {
-	Enabled  bool   `mapstructure:"enabled"`
-	Endpoint string `mapstructure:"endpoint"`
+	Enabled     bool    `mapstructure:"enabled"`
+	Exporter    string  `mapstructure:"exporter"` // otlp or stdout
+	Endpoint    string  `mapstructure:"endpoint"` // OTLP/HTTP traces URL
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+type HealthConfig struct {
+	CheckTimeout time.Duration `mapstructure:"check_timeout"` // per-dependency bound for /readyz
 }
 
 type LoggingConfig struct {
@@ -23,6 +23,14 @@ var (
 		[]string{"event_type"},
 	)
 
+	EventsDeduplicated = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "events_deduplicated_total",
+			Help: "Total number of duplicate events dropped",
+		},
+		[]string{"stage"},
+	)
+
 	EventProcessingErrors = promauto.NewCounter(
 		prometheus.CounterOpts{
 			Name: "event_processing_errors_total",
@@ -30,6 +38,45 @@ var (
 		},
 	)
 
+	EventProcessingRetries = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "event_processing_retries_total",
+			Help: "Total number of event processing retries",
+		},
+	)
+
+	EventsDeadLettered = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "events_dead_lettered_total",
+			Help: "Total number of messages sent to the dead-letter topic",
+		},
+		[]string{"topic"},
+	)
+
+	ProcessorBatchSize = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "processor_batch_size",
+			Help:    "Number of events per processor flush",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		},
+	)
+
+	ProcessorFlushes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "processor_flushes_total",
+			Help: "Total number of processor flush attempts",
+		},
+		[]string{"status"},
+	)
+
+	ProcessorFlushLatency = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "processor_flush_latency_seconds",
+			Help:    "Latency of processor flush attempts",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+	)
+
 	// Recommendation metrics
 	RecommendationRequests = promauto.NewCounter(
 		prometheus.CounterOpts{
@@ -61,6 +108,78 @@ var (
 		},
 	)
 
+	// Experiment metrics
+	ExperimentExposures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "experiment_exposures_total",
+			Help: "Total number of recommendation responses served per experiment variant",
+		},
+		[]string{"experiment", "variant"},
+	)
+
+	ExperimentLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "experiment_recommendation_latency_seconds",
+			Help:    "Latency of recommendation requests per experiment variant",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"experiment", "variant"},
+	)
+
+	EmbeddingIndexItems = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "embedding_index_items",
+			Help: "Number of items in the loaded embedding index",
+		},
+	)
+
+	EmbeddingIndexLoads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "embedding_index_loads_total",
+			Help: "Total number of embedding index loads",
+		},
+		[]string{"status"},
+	)
+
+	EmbeddingSearchLatency = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "embedding_search_latency_seconds",
+			Help:    "Latency of embedding index searches",
+			Buckets: []float64{.00005, .0001, .00025, .0005, .001, .0025, .005, .01, .025},
+		},
+	)
+
+	CFModelItems = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cf_model_items",
+			Help: "Number of items in the loaded collaborative filtering model",
+		},
+	)
+
+	CFModelLoads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cf_model_loads_total",
+			Help: "Total number of collaborative filtering model loads",
+		},
+		[]string{"status"},
+	)
+
+	CFScoringLatency = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "cf_scoring_latency_seconds",
+			Help:    "Latency of scoring all items for a user's factors",
+			Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05},
+		},
+	)
+
+	ModelActivations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "model_activations_total",
+			Help: "Total number of model versions activated through the registry",
+		},
+		[]string{"model", "action"},
+	)
+
 	// Redis metrics
 	RedisOperations = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -79,6 +198,22 @@ var (
 		[]string{"operation"},
 	)
 
+	RedisCommands = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "redis_commands_total",
+			Help: "Total number of Redis commands sent, including those inside pipelines",
+		},
+		[]string{"command", "status"},
+	)
+
+	RedisPoolStats = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "redis_pool_stats",
+			Help: "Redis connection pool statistics (hits, misses, timeouts, total_conns, idle_conns, stale_conns)",
+		},
+		[]string{"stat"},
+	)
+
 	// Kafka metrics
 	KafkaMessagesPublished = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -103,4 +238,28 @@ var (
 		},
 		[]string{"topic"},
 	)
+
+	KafkaConsumerLag = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kafka_consumer_lag",
+			Help: "Messages between the last fetched offset and the high-water mark, per partition",
+		},
+		[]string{"topic", "partition"},
+	)
+
+	KafkaReaderStats = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kafka_reader_stats",
+			Help: "Kafka reader gauges from Reader.Stats() (lag, offset, queue_length, queue_capacity)",
+		},
+		[]string{"topic", "partition", "stat"},
+	)
+
+	KafkaReaderEvents = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kafka_reader_events_total",
+			Help: "Kafka reader events from Reader.Stats() (dials, fetches, messages, rebalances, timeouts, errors)",
+		},
+		[]string{"topic", "event"},
+	)
 )
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// HeaderCarrier adapts Kafka message headers to a propagation.TextMapCarrier
+type HeaderCarrier struct {
+	headers *[]kafka.Header
+}
+
+// NewHeaderCarrier wraps the headers of msg
+func NewHeaderCarrier(msg *kafka.Message) HeaderCarrier {
+	return HeaderCarrier{headers: &msg.Headers}
+}
+
+// Get returns the value of the first header with the given key
+func (c HeaderCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set replaces the header with the given key, or appends it
+func (c HeaderCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys returns the header keys
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, len(*c.headers))
+	for i, h := range *c.headers {
+		keys[i] = h.Key
+	}
+	return keys
+}
+
+var _ propagation.TextMapCarrier = HeaderCarrier{}
+
+// InjectKafka writes the trace context of ctx into the message headers
+func InjectKafka(ctx context.Context, msg *kafka.Message) {
+	otel.GetTextMapPropagator().Inject(ctx, NewHeaderCarrier(msg))
+}
+
+// ExtractKafka returns ctx carrying the trace context found in the message headers
+func ExtractKafka(ctx context.Context, msg *kafka.Message) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, NewHeaderCarrier(msg))
+}
@@ -0,0 +1,36 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestKafkaPropagation(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanCtx)
+
+	msg := kafka.Message{Headers: []kafka.Header{{Key: "x-other", Value: []byte("kept")}}}
+	InjectKafka(ctx, &msg)
+	// Injecting twice replaces the header instead of duplicating it
+	InjectKafka(ctx, &msg)
+	require.Len(t, msg.Headers, 2)
+	assert.Equal(t, "kept", NewHeaderCarrier(&msg).Get("x-other"))
+
+	extracted := trace.SpanContextFromContext(ExtractKafka(context.Background(), &msg))
+	assert.Equal(t, spanCtx.TraceID(), extracted.TraceID())
+	assert.Equal(t, spanCtx.SpanID(), extracted.SpanID())
+	assert.True(t, extracted.IsRemote())
+}